@@ -5,14 +5,21 @@ import (
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
 )
 
-const StrategyAnnotationName = "strategy"
+const (
+	StrategyAnnotationName          = "strategy"
+	ModifiersAnnotationName         = "modifiers"
+	DisabledModifiersAnnotationName = "disabled-modifiers"
+)
 
 type strategySelectionConfig struct {
 	annotationPrefix string
 	strategies       map[strategy.YAMLName]strategy.Config
 	defaultStrategy  *strategy.Config
+	parameters       map[parameter.Name]parameter.Type
+	modifiers        map[string]strategy.ResultModifier
 }
 
 type providerSelectionConfig struct {
@@ -21,11 +28,50 @@ type providerSelectionConfig struct {
 	defaultProviders map[parameter.Name]provider.Name
 }
 
+func (cfg Config) strategySelection() strategySelectionConfig {
+	strategyConfigs := map[strategy.YAMLName]strategy.Config{}
+	for _, strategyCfg := range cfg.EnabledStrategies {
+		strategyConfigs[strategyCfg.YAMLName] = strategyCfg
+	}
+	availableParameters := map[parameter.Name]parameter.Type{}
+	for _, providerCfg := range cfg.EnabledProviders {
+		for name, paramType := range providerCfg.AvailableParameters {
+			availableParameters[name] = paramType
+		}
+	}
+	modifiers := map[string]strategy.ResultModifier{}
+	for _, modifier := range cfg.EnabledModifiers {
+		modifiers[modifier.Name] = modifier
+	}
+	selection := strategySelectionConfig{
+		annotationPrefix: cfg.AnnotationsPrefix,
+		strategies:       strategyConfigs,
+		parameters:       availableParameters,
+		modifiers:        modifiers,
+	}
+	if defaultStrategy, ok := strategyConfigs[cfg.DefaultStrategy]; ok {
+		selection.defaultStrategy = &defaultStrategy
+	}
+	return selection
+}
+
+func (cfg Config) providerSelection() providerSelectionConfig {
+	providersCfg := map[provider.Name]provider.Config{}
+	for _, providerCfg := range cfg.EnabledProviders {
+		providersCfg[providerCfg.Name] = providerCfg
+	}
+	return providerSelectionConfig{
+		annotationPrefix: cfg.AnnotationsPrefix,
+		providers:        providersCfg,
+		defaultProviders: cfg.DefaultParametersProviders,
+	}
+}
+
 func (cfg strategySelectionConfig) selectAppStrategy(appAnnotations map[string]string) (strategy.Config, error) {
 	name, ok := appAnnotations[cfg.annotationPrefix+StrategyAnnotationName]
 	if !ok {
 		if cfg.defaultStrategy != nil {
-			return *cfg.defaultStrategy, nil
+			return cfg.build(*cfg.defaultStrategy, appAnnotations)
 		}
 		return strategy.Config{}, fmt.Errorf("strategy not specified in app's annotations and default strategy isn't configured")
 	}
@@ -33,14 +79,84 @@ func (cfg strategySelectionConfig) selectAppStrategy(appAnnotations map[string]s
 	if !ok {
 		return strategy.Config{}, fmt.Errorf("'%s' strategy specified in annotations doesn't exist", name)
 	}
+	return cfg.build(selected, appAnnotations)
+}
+
+func (cfg strategySelectionConfig) build(selected strategy.Config, appAnnotations map[string]string) (strategy.Config, error) {
+	if selected.Build == nil {
+		return cfg.selectModifiers(selected, appAnnotations)
+	}
+	built, err := selected.Build(selected, strategy.BuildContext{
+		AnnotationsPrefix: cfg.annotationPrefix,
+		Annotations:       appAnnotations,
+		Strategies:        cfg.strategies,
+		Parameters:        cfg.parameters,
+	})
+	if err != nil {
+		return strategy.Config{}, fmt.Errorf("failed to build '%s' strategy: %w", selected.Name, err)
+	}
+	if err := built.Validate(); err != nil {
+		return strategy.Config{}, fmt.Errorf("validation failed for built '%s' strategy: %w", selected.Name, err)
+	}
+	return cfg.selectModifiers(built, appAnnotations)
+}
+
+// selectModifiers replaces strategy's modifiers with the ones listed in app's annotations and removes disabled ones.
+// Modifiers can be chosen among enabled modifiers and the strategy's own modifiers
+func (cfg strategySelectionConfig) selectModifiers(selected strategy.Config, appAnnotations map[string]string) (strategy.Config, error) {
+	listed, hasListed := appAnnotations[cfg.annotationPrefix+ModifiersAnnotationName]
+	disabled, hasDisabled := appAnnotations[cfg.annotationPrefix+DisabledModifiersAnnotationName]
+	if !hasListed && !hasDisabled {
+		return selected, nil
+	}
+	available := map[string]strategy.ResultModifier{}
+	for name, modifier := range cfg.modifiers {
+		available[name] = modifier
+	}
+	for _, modifier := range selected.ResultModifiers {
+		available[modifier.Name] = modifier
+	}
+	chain := selected.ResultModifiers
+
+	if hasListed {
+		chain = []strategy.ResultModifier{}
+		for _, name := range common.SplitList(listed) {
+			modifier, ok := available[name]
+			if !ok {
+				return strategy.Config{}, fmt.Errorf("'%s' modifier specified in annotations doesn't exist", name)
+			}
+			chain = append(chain, modifier)
+		}
+	}
+	if hasDisabled {
+		disabledNames := map[string]bool{}
+		for _, name := range common.SplitList(disabled) {
+			if _, ok := available[name]; !ok {
+				return strategy.Config{}, fmt.Errorf("'%s' modifier disabled in annotations doesn't exist", name)
+			}
+			disabledNames[name] = true
+		}
+		enabled := []strategy.ResultModifier{}
+		for _, modifier := range chain {
+			if !disabledNames[modifier.Name] {
+				enabled = append(enabled, modifier)
+			}
+		}
+		chain = enabled
+	}
+	selected.ResultModifiers = chain
 	return selected, nil
 }
 
+// selectFor selects providers of the strategy's parameters. Optional parameters, i.e. the ones with default values,
+// are requested from their default providers only when these providers are used for other parameters of the app,
+// so that an optional parameter doesn't make the app query one more provider
 func (cfg providerSelectionConfig) selectFor(
 	strategyCfg strategy.Config, annotations map[string]string) (map[provider.Name][]parameter.Name, parameter.Values, error) {
 
 	requiredParams := map[provider.Name][]parameter.Name{}
 	yamlProvided := parameter.EmptyValues()
+	optionalDefaults := map[parameter.Name]provider.Name{}
 
 	for paramName, spec := range strategyCfg.GetRequiredParameters() {
 		annValue, ok := annotations[cfg.annotationPrefix+string(paramName)]
@@ -66,6 +182,10 @@ func (cfg providerSelectionConfig) selectFor(
 					providerCfg.Name, paramName, paramType.Name,
 				)
 			}
+			if spec.DefaultValue != nil {
+				optionalDefaults[paramName] = providerCfg.Name
+				continue
+			}
 			requiredParams[providerCfg.Name] = append(requiredParams[providerCfg.Name], paramName)
 			continue
 
@@ -92,6 +212,15 @@ func (cfg providerSelectionConfig) selectFor(
 		}
 		continue
 	}
+	for paramName, provName := range optionalDefaults {
+		if _, ok := requiredParams[provName]; ok {
+			requiredParams[provName] = append(requiredParams[provName], paramName)
+			continue
+		}
+		if err := cfg.trySpecDefault(paramName, strategyCfg.GetRequiredParameters()[paramName], yamlProvided); err != nil {
+			return nil, parameter.Values{}, err
+		}
+	}
 	return requiredParams, yamlProvided, nil
 }
 
@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"errors"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
@@ -112,6 +113,126 @@ func TestStrategySelectorConfig(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestStrategySelectorConfig_withBuild(t *testing.T) {
+	cfg := strategySelectionConfig{
+		annotationPrefix: "prefix/",
+		strategies: map[strategy.YAMLName]strategy.Config{
+			"built": {
+				Name:     "built",
+				YAMLName: "built",
+				Execute: func(app scalable.App, values parameter.Values) (strategy.Result, error) {
+					panic("test implementation")
+				},
+				Build: func(cfg strategy.Config, ctx strategy.BuildContext) (strategy.Config, error) {
+					paramName, ok := ctx.Annotations[ctx.AnnotationsPrefix+"param"]
+					if !ok {
+						return strategy.Config{}, errors.New("param is not specified")
+					}
+					cfg.RequiredParameters = strategy.RequiredParameters{
+						parameter.Name(paramName): {Type: parameter.Int},
+					}
+					cfg.Build = nil
+					return cfg, nil
+				},
+			},
+		},
+	}
+	selected, err := cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy": "built",
+			"prefix/param":    "int",
+		},
+	)
+	require.NoError(t, err)
+	require.Contains(t, selected.GetRequiredParameters(), parameter.Name("int"))
+
+	_, err = cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy": "built",
+		},
+	)
+	require.Error(t, err)
+}
+
+func TestStrategySelectorConfig_withModifiers(t *testing.T) {
+	makeModifier := func(name string, param parameter.Name) strategy.ResultModifier {
+		return strategy.ResultModifier{
+			Name: name,
+			RequiredParameters: strategy.RequiredParameters{
+				param: {Type: parameter.Int},
+			},
+			Execute: func(app scalable.App, values parameter.Values, result strategy.Result) (strategy.Result, error) {
+				return result, nil
+			},
+		}
+	}
+	strategyCfg := makeStrategyConfig(map[parameter.Name]strategy.ParameterSpec{})
+	strategyCfg.ResultModifiers = []strategy.ResultModifier{
+		makeModifier("first", "first-param"),
+		makeModifier("second", "second-param"),
+	}
+	cfg := strategySelectionConfig{
+		annotationPrefix: "prefix/",
+		strategies: map[strategy.YAMLName]strategy.Config{
+			"test_strategy": strategyCfg,
+		},
+		modifiers: map[string]strategy.ResultModifier{
+			"registered": makeModifier("registered", "registered-param"),
+		},
+	}
+	modifierNames := func(cfg strategy.Config) []string {
+		var names []string
+		for _, modifier := range cfg.ResultModifiers {
+			names = append(names, modifier.Name)
+		}
+		return names
+	}
+
+	selected, err := cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy": "test_strategy",
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second"}, modifierNames(selected))
+
+	selected, err = cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy":  "test_strategy",
+			"prefix/modifiers": "registered, second, first",
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"registered", "second", "first"}, modifierNames(selected))
+	require.Contains(t, selected.GetRequiredParameters(), parameter.Name("registered-param"))
+
+	selected, err = cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy":           "test_strategy",
+			"prefix/disabled-modifiers": "first",
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"second"}, modifierNames(selected))
+	require.NotContains(t, selected.GetRequiredParameters(), parameter.Name("first-param"))
+
+	_, err = cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy":  "test_strategy",
+			"prefix/modifiers": "nonexistent",
+		},
+	)
+	require.Error(t, err)
+
+	_, err = cfg.selectAppStrategy(
+		map[string]string{
+			"prefix/strategy":           "test_strategy",
+			"prefix/disabled-modifiers": "nonexistent",
+		},
+	)
+	require.Error(t, err)
+}
+
 func TestProviderSelectorConfig(t *testing.T) {
 	params, yamlProvided, err := providerSelectionCfg.selectFor(
 		makeStrategyConfig(
@@ -189,6 +310,66 @@ func TestProviderSelectorConfig_withDefaults(t *testing.T) {
 	require.Equal(t, 0, yamlProvided.Len())
 }
 
+func TestProviderSelectorConfig_withOptionalDefaults(t *testing.T) {
+	selectionCfg := providerSelectionConfig{
+		annotationPrefix: "prefix/",
+		providers: map[provider.Name]provider.Config{
+			"int_provider": providerCfgs["int_provider"],
+			"queue_provider": {
+				Name: "queue_provider",
+				AvailableParameters: map[parameter.Name]parameter.Type{
+					"int":      parameter.Int,
+					"optional": parameter.IntMap,
+				},
+			},
+		},
+		defaultProviders: map[parameter.Name]provider.Name{
+			"int":      "queue_provider",
+			"optional": "queue_provider",
+		},
+	}
+	strategyCfg := makeStrategyConfig(
+		map[parameter.Name]strategy.ParameterSpec{
+			"int":      {Type: parameter.Int},
+			"optional": {Type: parameter.IntMap, DefaultValue: map[string]int(nil)},
+		},
+	)
+	testCases := []struct {
+		name             string
+		annotations      map[string]string
+		expectedParams   map[provider.Name][]parameter.Name
+		expectedProvided int
+	}{
+		{
+			name:           "default provider is used for other parameters",
+			annotations:    map[string]string{},
+			expectedParams: map[provider.Name][]parameter.Name{"queue_provider": {"int", "optional"}},
+		},
+		{
+			name:             "default provider isn't used for other parameters",
+			annotations:      map[string]string{"prefix/int": "int_provider"},
+			expectedParams:   map[provider.Name][]parameter.Name{"int_provider": {"int"}},
+			expectedProvided: 1,
+		},
+		{
+			name:        "provider is selected in annotations",
+			annotations: map[string]string{"prefix/int": "int_provider", "prefix/optional": "queue_provider"},
+			expectedParams: map[provider.Name][]parameter.Name{
+				"int_provider":   {"int"},
+				"queue_provider": {"optional"},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			params, yamlProvided, err := selectionCfg.selectFor(strategyCfg, testCase.annotations)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedParams, params)
+			require.Equal(t, testCase.expectedProvided, yamlProvided.Len())
+		})
+	}
+}
+
 func TestProviderSelectorConfig_withError(t *testing.T) {
 	// Parameter with no default value not specified in annotations
 	_, _, err := providerSelectionCfg.selectFor(
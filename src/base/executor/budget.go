@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"k8s.io/klog"
+	"sort"
+	"strconv"
+)
+
+const PriorityAnnotationName = "priority"
+
+// BudgetConfig limits the total number of replicas that can be handed out to apps, zero values mean no limit
+type BudgetConfig struct {
+	// Cluster limits the total number of replicas of all apps
+	Cluster int
+	// Namespace limits the total number of replicas of apps in each namespace
+	Namespace int
+}
+
+func (cfg BudgetConfig) enabled() bool {
+	return cfg.Cluster > 0 || cfg.Namespace > 0
+}
+
+type budgetRequest struct {
+	key       string
+	namespace string
+	priority  int
+	current   int
+	required  int
+}
+
+// allocateBudget returns the number of replicas allowed for each request.
+// Scaling down is always allowed, replicas left in the budget are handed out to apps scaling up
+// by their priority and then one by one to the app with the largest unmet need
+func allocateBudget(cfg BudgetConfig, requests []budgetRequest) []int {
+	allowed := make([]int, len(requests))
+	clusterUsed := 0
+	namespaceUsed := map[string]int{}
+	var scalingUp []int
+
+	for i, request := range requests {
+		allowed[i] = request.current
+		if request.required < request.current {
+			allowed[i] = request.required
+		}
+		clusterUsed += allowed[i]
+		namespaceUsed[request.namespace] += allowed[i]
+		if request.required > allowed[i] {
+			scalingUp = append(scalingUp, i)
+		}
+	}
+	sort.SliceStable(scalingUp, func(i, j int) bool {
+		left, right := requests[scalingUp[i]], requests[scalingUp[j]]
+		if left.priority != right.priority {
+			return left.priority > right.priority
+		}
+		return left.key < right.key
+	})
+	hasRoom := func(request budgetRequest) bool {
+		if cfg.Cluster > 0 && clusterUsed >= cfg.Cluster {
+			return false
+		}
+		return cfg.Namespace <= 0 || namespaceUsed[request.namespace] < cfg.Namespace
+	}
+	for start := 0; start < len(scalingUp); {
+		end := start
+		for end < len(scalingUp) && requests[scalingUp[end]].priority == requests[scalingUp[start]].priority {
+			end++
+		}
+		for {
+			selected, largestNeed := -1, 0
+			for _, i := range scalingUp[start:end] {
+				need := requests[i].required - allowed[i]
+				if need > largestNeed && hasRoom(requests[i]) {
+					selected, largestNeed = i, need
+				}
+			}
+			if selected < 0 {
+				break
+			}
+			allowed[selected]++
+			clusterUsed++
+			namespaceUsed[requests[selected].namespace]++
+		}
+		start = end
+	}
+	return allowed
+}
+
+func parsePriority(app scalable.App, annotationsPrefix string) (int, error) {
+	value, ok := (*app.Annotations)[annotationsPrefix+PriorityAnnotationName]
+	if !ok {
+		return 0, nil
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority '%s': %w", value, err)
+	}
+	return priority, nil
+}
+
+// applyBudget limits strategies results by the configured budget once all of them are collected
+func (ex executor) applyBudget(collected <-chan strategy.Result, out chan<- strategy.Result) {
+	defer close(out)
+	results := map[string]strategy.Result{}
+	for result := range collected {
+		results[result.App.Key] = result
+	}
+	requests := make([]budgetRequest, len(ex.apps))
+	for i, app := range ex.apps {
+		requests[i] = budgetRequest{
+			key:       app.Key,
+			namespace: app.Namespace,
+			priority:  ex.priorities[app],
+			current:   app.Replicas,
+			required:  app.Replicas,
+		}
+		if result, ok := results[app.Key]; ok && !result.Skip {
+			requests[i].required = result.RequiredReplicas
+		}
+	}
+	allowed := allocateBudget(ex.config.Budget, requests)
+
+	for i, request := range requests {
+		result, ok := results[request.key]
+		if !ok {
+			continue
+		}
+		if !result.Skip && allowed[i] < result.RequiredReplicas {
+			if klog.V(2) {
+				klog.Infof(
+					"%s's required replicas number (%d) is limited to %d by workers budget",
+					request.key, result.RequiredReplicas, allowed[i],
+				)
+			}
+			if allowed[i] == request.current {
+				result = strategy.Result{App: result.App, Skip: true, SkipReason: "workers budget is exhausted"}
+			} else {
+				result.RequiredReplicas = allowed[i]
+			}
+		}
+		out <- result
+	}
+}
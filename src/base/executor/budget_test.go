@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestAllocateBudget(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      BudgetConfig
+		requests []budgetRequest
+		expected []int
+	}{
+		{
+			name: "enough budget",
+			cfg:  BudgetConfig{Cluster: 20},
+			requests: []budgetRequest{
+				{key: "a", current: 2, required: 5},
+				{key: "b", current: 3, required: 6},
+			},
+			expected: []int{5, 6},
+		},
+		{
+			name: "higher priority first",
+			cfg:  BudgetConfig{Cluster: 10},
+			requests: []budgetRequest{
+				{key: "a", current: 2, required: 8},
+				{key: "b", current: 2, required: 8, priority: 1},
+			},
+			expected: []int{2, 8},
+		},
+		{
+			name: "largest need first within priority",
+			cfg:  BudgetConfig{Cluster: 10},
+			requests: []budgetRequest{
+				{key: "a", current: 1, required: 3},
+				{key: "b", current: 1, required: 9},
+			},
+			expected: []int{2, 8},
+		},
+		{
+			name: "scale down frees budget",
+			cfg:  BudgetConfig{Cluster: 10},
+			requests: []budgetRequest{
+				{key: "a", current: 6, required: 2},
+				{key: "b", current: 4, required: 9},
+			},
+			expected: []int{2, 8},
+		},
+		{
+			name: "apps without changes use budget",
+			cfg:  BudgetConfig{Cluster: 10},
+			requests: []budgetRequest{
+				{key: "a", current: 7, required: 7},
+				{key: "b", current: 1, required: 5},
+			},
+			expected: []int{7, 3},
+		},
+		{
+			name: "exceeded budget",
+			cfg:  BudgetConfig{Cluster: 5},
+			requests: []budgetRequest{
+				{key: "a", current: 6, required: 7},
+				{key: "b", current: 1, required: 0},
+			},
+			expected: []int{6, 0},
+		},
+		{
+			name: "namespace budget",
+			cfg:  BudgetConfig{Cluster: 20, Namespace: 6},
+			requests: []budgetRequest{
+				{key: "a", namespace: "first", current: 1, required: 5},
+				{key: "b", namespace: "first", current: 1, required: 5},
+				{key: "c", namespace: "second", current: 1, required: 5},
+			},
+			expected: []int{3, 3, 5},
+		},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, allocateBudget(tc.cfg, tc.requests), tc.name)
+	}
+}
+
+func TestLaunch_withBudget(t *testing.T) {
+	cfg := Config{
+		EnabledStrategies: []strategy.Config{
+			{
+				Name:     "required",
+				YAMLName: "required",
+				Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+					required, err := strconv.Atoi((*app.Annotations)["required"])
+					return strategy.Result{RequiredReplicas: required}, err
+				},
+			},
+		},
+		DefaultStrategy: "required",
+		Budget:          BudgetConfig{Cluster: 6},
+	}
+	apps := []scalable.App{
+		{Key: "ns/low", Replicas: 1, Annotations: &map[string]string{"required": "5"}},
+		{Key: "ns/high", Replicas: 1, Annotations: &map[string]string{"required": "4", "priority": "1"}},
+		{Key: "ns/idle", Replicas: 1, Annotations: &map[string]string{"required": "1"}},
+	}
+	results, errs := Launch(cfg, apps)
+	collectedErrs := collectErrors(errs)
+	collected := map[string]strategy.Result{}
+	for result := range results {
+		collected[result.App.Key] = result
+	}
+	require.Empty(t, <-collectedErrs)
+	require.Len(t, collected, 3)
+	require.Equal(t, 4, collected["ns/high"].RequiredReplicas)
+	require.True(t, collected["ns/low"].Skip)
+	require.Equal(t, 1, collected["ns/idle"].RequiredReplicas)
+}
@@ -4,16 +4,20 @@ import (
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
 )
 
 type Config struct {
 	EnabledStrategies          []strategy.Config
+	EnabledModifiers           []strategy.ResultModifier
 	EnabledProviders           []provider.Config
 	AnnotationsPrefix          string
 	DefaultStrategy            strategy.YAMLName
 	DefaultParametersProviders map[parameter.Name]provider.Name
 	FallbackToDefaultStrategy  bool
+	StateStore                 state.Store
+	Budget                     BudgetConfig
 }
 
 func Launch(config Config, apps []scalable.App) (<-chan strategy.Result, <-chan Error) {
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+)
+
+// deferredParameters looks up app's deferred parameters from the providers selected for them
+// when the app's parameters are scheduled
+type deferredParameters struct {
+	app       scalable.App
+	specs     strategy.RequiredParameters
+	providers map[parameter.Name]provider.Config
+}
+
+// split removes deferred parameters from the ones requested from providers and keeps their providers for lookups,
+// default values of deferred parameters are set to the values provided before the strategy is executed
+func (d *deferredParameters) split(
+	providers map[provider.Name]provider.Config, requested map[provider.Name][]parameter.Name, provided parameter.Values) error {
+
+	d.providers = map[parameter.Name]provider.Config{}
+	for provName, params := range requested {
+		collected := make([]parameter.Name, 0, len(params))
+		for _, name := range params {
+			spec := d.specs[name]
+			if !spec.Deferred {
+				collected = append(collected, name)
+				continue
+			}
+			if err := provided.Insert(name, spec.DefaultValue, spec.Type); err != nil {
+				return fmt.Errorf("failed to set default value of deferred '%s' parameter: %w", name, err)
+			}
+			d.providers[name] = providers[provName]
+		}
+		if len(collected) == 0 {
+			delete(requested, provName)
+			continue
+		}
+		requested[provName] = collected
+	}
+	return nil
+}
+
+func (d *deferredParameters) Lookup(names ...parameter.Name) (parameter.Values, error) {
+	values := parameter.EmptyValues()
+	requested := map[provider.Name][]parameter.Name{}
+	configs := map[provider.Name]provider.Config{}
+	for _, name := range names {
+		providerCfg, ok := d.providers[name]
+		if !ok {
+			continue
+		}
+		requested[providerCfg.Name] = append(requested[providerCfg.Name], name)
+		configs[providerCfg.Name] = providerCfg
+	}
+	for provName, params := range requested {
+		resultCtx := provider.Launch(configs[provName], map[scalable.App][]parameter.Name{d.app: params})[d.app]
+		for {
+			result, ok := resultCtx.GetNextResult()
+			if !ok {
+				break
+			}
+			if result.Error != nil {
+				resultCtx.Cancel()
+				return parameter.Values{}, fmt.Errorf("'%s' provider failed: %w", provName, result.Error)
+			}
+			converted, err := convertParameters(result.Parameters, d.specs)
+			if err != nil {
+				resultCtx.Cancel()
+				return parameter.Values{}, err
+			}
+			values = values.Merge(converted)
+		}
+	}
+	return values, nil
+}
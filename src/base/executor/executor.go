@@ -3,6 +3,7 @@ package executor
 import (
 	"fmt"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
 	"sync"
 )
@@ -11,6 +12,8 @@ type executor struct {
 	apps                  []scalable.App
 	config                Config
 	appsStrategiesConfigs map[scalable.App]strategy.Config
+	priorities            map[scalable.App]int
+	deferred              map[scalable.App]*deferredParameters
 	out                   output
 	done                  chan struct{}
 }
@@ -25,6 +28,24 @@ func makeExecutor(config Config, apps []scalable.App) (executor, <-chan strategy
 	errs := make(chan Error)
 	results := make(chan strategy.Result)
 
+	if config.StateStore != nil {
+		appsWithState := make([]scalable.App, len(apps))
+		for i, app := range apps {
+			app.State = state.For(config.StateStore, app.Key)
+			appsWithState[i] = app
+		}
+		apps = appsWithState
+	}
+	deferred := map[scalable.App]*deferredParameters{}
+	appsWithDeferred := make([]scalable.App, len(apps))
+	for i, app := range apps {
+		appDeferred := &deferredParameters{}
+		app.Deferred = appDeferred
+		appDeferred.app = app
+		deferred[app] = appDeferred
+		appsWithDeferred[i] = app
+	}
+	apps = appsWithDeferred
 	ex := executor{
 		apps:   apps,
 		config: config,
@@ -33,38 +54,48 @@ func makeExecutor(config Config, apps []scalable.App) (executor, <-chan strategy
 			errors:   errs,
 			errorsWg: &sync.WaitGroup{},
 		},
-		done: make(chan struct{}),
+		done:     make(chan struct{}),
+		deferred: deferred,
 	}
 	appsStrategies := map[scalable.App]strategy.Config{}
-	strategyConfigs := map[strategy.YAMLName]strategy.Config{}
 
-	for _, strategyCfg := range ex.config.EnabledStrategies {
-		strategyConfigs[strategyCfg.YAMLName] = strategyCfg
-	}
 	reportError := func(app scalable.App, err error) {
+		defer ex.out.errorsWg.Done()
 		ex.out.errors <- BaseError{
 			App: app,
 			Err: err,
 		}
 	}
-	strategySelector := strategySelectionConfig{
-		annotationPrefix: config.AnnotationsPrefix,
-		strategies:       strategyConfigs,
-	}
-	defaultStrategy, ok := strategyConfigs[config.DefaultStrategy]
-	if ok {
-		strategySelector.defaultStrategy = &defaultStrategy
-	}
+	strategySelector := config.strategySelection()
+
+	priorities := map[scalable.App]int{}
 
 	for _, app := range apps {
+		if config.Budget.enabled() {
+			priority, err := parsePriority(app, config.AnnotationsPrefix)
+			if err != nil {
+				ex.out.errorsWg.Add(1)
+				go reportError(app, err)
+			}
+			priorities[app] = priority
+		}
 		selected, err := strategySelector.selectAppStrategy(*app.Annotations)
 		if err != nil {
+			ex.out.errorsWg.Add(1)
 			go reportError(app, fmt.Errorf("could not select strategy: %w", err))
 			continue
 		}
 		appsStrategies[app] = selected
 	}
 	ex.appsStrategiesConfigs = appsStrategies
+	ex.priorities = priorities
+
+	if config.Budget.enabled() {
+		// Results are sent after the budget is allocated among all of them
+		collected := make(chan strategy.Result)
+		ex.out.results = collected
+		go ex.applyBudget(collected, results)
+	}
 	return ex, results, errs
 }
 
@@ -75,6 +106,7 @@ func (ex executor) start() {
 }
 
 func (ex executor) cleanup() {
+	ex.out.errorsWg.Wait()
 	close(ex.out.errors)
 	close(ex.out.results)
 	close(ex.done)
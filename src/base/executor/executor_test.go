@@ -0,0 +1,162 @@
+package executor
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+// collectErrors reads errors until the channel is closed and sends them at once,
+// so they can be asserted in the test goroutine
+func collectErrors(errs <-chan Error) <-chan []Error {
+	collected := make(chan []Error, 1)
+	go func() {
+		var all []Error
+		for err := range errs {
+			all = append(all, err)
+		}
+		collected <- all
+	}()
+	return collected
+}
+
+func TestLaunch_withStateStore(t *testing.T) {
+	store := state.NewMemoryStore()
+	cfg := Config{
+		EnabledStrategies: []strategy.Config{
+			{
+				Name:     "stateful",
+				YAMLName: "stateful",
+				Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+					var rounds int
+					if _, err := app.State.Get("rounds", &rounds); err != nil {
+						return strategy.Result{}, err
+					}
+					rounds++
+					if err := app.State.Set("rounds", rounds); err != nil {
+						return strategy.Result{}, err
+					}
+					return strategy.Result{RequiredReplicas: rounds}, nil
+				},
+			},
+		},
+		DefaultStrategy: "stateful",
+		StateStore:      store,
+	}
+	apps := []scalable.App{{Key: "ns/app", Name: "app", Annotations: &map[string]string{}}}
+
+	for round := 1; round <= 3; round++ {
+		results, errs := Launch(cfg, apps)
+		collectedErrs := collectErrors(errs)
+		var collected []strategy.Result
+		for result := range results {
+			collected = append(collected, result)
+		}
+		require.Empty(t, <-collectedErrs)
+		require.Len(t, collected, 1)
+		require.Equal(t, round, collected[0].RequiredReplicas)
+	}
+}
+
+func TestLaunch_withDeferredParameters(t *testing.T) {
+	testCases := []struct {
+		name             string
+		required         int
+		expectedReplicas int
+		expectedRequests [][]parameter.Name
+	}{
+		{
+			name:             "not looked up",
+			required:         4,
+			expectedReplicas: 4,
+			expectedRequests: [][]parameter.Name{{"int"}},
+		},
+		{
+			name:             "looked up",
+			required:         1,
+			expectedReplicas: 2,
+			expectedRequests: [][]parameter.Name{{"int"}, {"deferred"}},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mx := sync.Mutex{}
+			var requests [][]parameter.Name
+			cfg := Config{
+				EnabledProviders: []provider.Config{
+					{
+						Name: "int_provider",
+						AvailableParameters: map[parameter.Name]parameter.Type{
+							"int":      parameter.Int,
+							"deferred": parameter.Int,
+						},
+						Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+							for _, ctx := range appsCtx {
+								mx.Lock()
+								requests = append(requests, ctx.Parameters)
+								mx.Unlock()
+								params := provider.ProvidedParameters{}
+								for _, name := range ctx.Parameters {
+									params.Set(name, 2)
+								}
+								ctx.PutResult(params)
+								ctx.Finish()
+							}
+						},
+					},
+				},
+				EnabledStrategies: []strategy.Config{
+					{
+						Name:               "deferred",
+						YAMLName:           "deferred",
+						RequiredParameters: strategy.RequiredParameters{"int": {Type: parameter.Int}},
+						ResultModifiers: []strategy.ResultModifier{
+							{
+								Name: "deferred",
+								RequiredParameters: strategy.RequiredParameters{
+									"deferred": {Type: parameter.Int, DefaultValue: 0, Deferred: true},
+								},
+								Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+									if params.Ints["deferred"] != 0 || prev.RequiredReplicas >= app.Replicas {
+										return prev, nil
+									}
+									deferred, err := app.Deferred.Lookup("deferred")
+									if err != nil {
+										return strategy.Result{}, err
+									}
+									prev.RequiredReplicas = deferred.Ints["deferred"]
+									return prev, nil
+								},
+							},
+						},
+						Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+							return strategy.Result{RequiredReplicas: testCase.required}, nil
+						},
+					},
+				},
+				DefaultStrategy: "deferred",
+				DefaultParametersProviders: map[parameter.Name]provider.Name{
+					"int":      "int_provider",
+					"deferred": "int_provider",
+				},
+			}
+			apps := []scalable.App{{Key: "ns/app", Name: "app", Replicas: 3, Annotations: &map[string]string{}}}
+
+			results, errs := Launch(cfg, apps)
+			collectedErrs := collectErrors(errs)
+			var collected []strategy.Result
+			for result := range results {
+				collected = append(collected, result)
+			}
+			require.Empty(t, <-collectedErrs)
+			require.Len(t, collected, 1)
+			require.Equal(t, testCase.expectedReplicas, collected[0].RequiredReplicas)
+			require.Equal(t, testCase.expectedRequests, requests)
+		})
+	}
+}
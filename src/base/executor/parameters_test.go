@@ -17,6 +17,7 @@ func Test_ConvertParameters(t *testing.T) {
 		"bool_true":  true,
 		"bool_false": false,
 		"duration":   time.Duration(2520000000000),
+		"int_map":    map[string]int{"key": 1},
 	}
 	config := strategy.RequiredParameters{
 		"int":        {Type: parameter.Int},
@@ -25,6 +26,7 @@ func Test_ConvertParameters(t *testing.T) {
 		"bool_true":  {Type: parameter.Bool},
 		"bool_false": {Type: parameter.Bool},
 		"duration":   {Type: parameter.Duration},
+		"int_map":    {Type: parameter.IntMap},
 	}
 	converted, err := convertParameters(provided, config)
 	if err != nil {
@@ -37,6 +39,7 @@ func Test_ConvertParameters(t *testing.T) {
 			Strings:   map[parameter.Name]string{"string": "120"},
 			Booleans:  map[parameter.Name]bool{"bool_true": true, "bool_false": false},
 			Durations: map[parameter.Name]time.Duration{"duration": time.Duration(2520000000000)},
+			IntMaps:   map[parameter.Name]map[string]int{"int_map": {"key": 1}},
 		},
 		converted,
 		"Expected provided parameters to be properly converted",
@@ -15,22 +15,16 @@ type providerSchedulingResult struct {
 }
 
 func (ex executor) scheduleProviders() providerSchedulingResult {
-	providersCfg := map[provider.Name]provider.Config{}
-
-	for _, providerCfg := range ex.config.EnabledProviders {
-		providersCfg[providerCfg.Name] = providerCfg
-	}
-
 	requiredParams := map[provider.Name]provider.RequiredAppsParameters{}
 	staticAppParameters := map[scalable.App]parameter.Values{}
 
-	providerSelection := providerSelectionConfig{
-		annotationPrefix: ex.config.AnnotationsPrefix,
-		providers:        providersCfg,
-		defaultProviders: ex.config.DefaultParametersProviders,
-	}
+	providerSelection := ex.config.providerSelection()
 	for _, app := range ex.apps {
-		strategyCfg := ex.appsStrategiesConfigs[app]
+		strategyCfg, ok := ex.appsStrategiesConfigs[app]
+		if !ok {
+			// Strategy selection error is already reported
+			continue
+		}
 		appProvidersParameters, providedValues, err := providerSelection.selectFor(strategyCfg, *app.Annotations)
 		if err != nil {
 			ex.out.errors <- BaseError{
@@ -40,6 +34,16 @@ func (ex executor) scheduleProviders() providerSchedulingResult {
 			}
 			continue
 		}
+		deferred := ex.deferred[app]
+		deferred.specs = strategyCfg.GetRequiredParameters()
+		if err := deferred.split(providerSelection.providers, appProvidersParameters, providedValues); err != nil {
+			ex.out.errors <- BaseError{
+				App:      app,
+				Strategy: strategyCfg,
+				Err:      err,
+			}
+			continue
+		}
 		for provName, paramNames := range appProvidersParameters {
 			providerParams, ok := requiredParams[provName]
 			if !ok {
@@ -54,7 +58,7 @@ func (ex executor) scheduleProviders() providerSchedulingResult {
 	appsProvidersResults := map[scalable.App][]provider.ResultAppContext{}
 
 	for name, appsParameters := range requiredParams {
-		provResults := provider.Launch(providersCfg[name], appsParameters)
+		provResults := provider.Launch(providerSelection.providers[name], appsParameters)
 		for app, provResult := range provResults {
 			appsProvidersResults[app] = append(appsProvidersResults[app], provResult)
 		}
@@ -73,6 +77,11 @@ func (ex executor) scheduleStrategies(schedulingResult providerSchedulingResult)
 	appsWg := sync.WaitGroup{}
 
 	for _, app := range ex.apps {
+		yamlProvided, ok := appsParameters[app]
+		if !ok {
+			// Parameters selection error is already reported
+			continue
+		}
 		strategyCfg := ex.appsStrategiesConfigs[app]
 		providersAppContexts := schedulingResult.appsProvidersResults[app]
 
@@ -94,7 +103,7 @@ func (ex executor) scheduleStrategies(schedulingResult providerSchedulingResult)
 			close(appParams)
 		}()
 		appsWg.Add(1)
-		go ex.collectAppParams(app, strategyCfg, appsParameters[app], appParams, cancelAppProviders, appsWg.Done)
+		go ex.collectAppParams(app, strategyCfg, yamlProvided, appParams, cancelAppProviders, appsWg.Done)
 	}
 	go func() {
 		appsWg.Wait()
@@ -152,18 +161,18 @@ func (ex executor) collectAppParams(
 	defer done()
 	collectedParams := yamlProvided
 
+	if strategy.Ready(strategyCfg, collectedParams) {
+		cancelAppProviders()
+		ex.executeStrategy(app, strategyCfg, collectedParams)
+		return
+	}
 	for appParam := range appParams {
 		merged := collectedParams.Merge(appParam)
 		collectedParams = merged
 
 		if strategy.Ready(strategyCfg, merged) {
 			cancelAppProviders()
-			result, err := strategy.Execute(strategyCfg, app, merged)
-			if err != nil {
-				ex.out.errors <- BaseError{App: app, Strategy: strategyCfg, Err: err}
-				return
-			}
-			ex.out.results <- result
+			ex.executeStrategy(app, strategyCfg, merged)
 			return
 		}
 	}
@@ -178,6 +187,15 @@ func (ex executor) collectAppParams(
 	}
 }
 
+func (ex executor) executeStrategy(app scalable.App, strategyCfg strategy.Config, params parameter.Values) {
+	result, err := strategy.Execute(strategyCfg, app, params)
+	if err != nil {
+		ex.out.errors <- BaseError{App: app, Strategy: strategyCfg, Err: err}
+		return
+	}
+	ex.out.results <- result
+}
+
 func (ex executor) scheduleCleanup(done <-chan struct{}) {
 	go func() {
 		<-done
@@ -3,6 +3,7 @@ package executor
 import (
 	"fmt"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
 )
 
 func (cfg Config) Validate() []error {
@@ -21,6 +22,21 @@ func (cfg Config) Validate() []error {
 		errs = append(errs, fmt.Errorf("default strategy '%s' not found among enabled strategies", defaultStrategyName))
 	}
 
+	enabledModifiers := map[string]bool{}
+	for _, modifier := range cfg.EnabledModifiers {
+		if err := modifier.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("validation failed for '%s' modifier: %w", modifier.Name, err))
+		}
+		if enabledModifiers[modifier.Name] {
+			errs = append(errs, fmt.Errorf("modifier '%s' is enabled more than once", modifier.Name))
+		}
+		enabledModifiers[modifier.Name] = true
+	}
+
+	if cfg.Budget.Cluster < 0 || cfg.Budget.Namespace < 0 {
+		errs = append(errs, fmt.Errorf("budget must not be negative, got %+v", cfg.Budget))
+	}
+
 	enabledProviders := map[provider.Name]provider.Config{}
 	for _, enabledProvider := range cfg.EnabledProviders {
 		enabledProviders[enabledProvider.Name] = enabledProvider
@@ -37,3 +53,18 @@ func (cfg Config) Validate() []error {
 	}
 	return errs
 }
+
+// ValidateApp checks that strategy and its parameters can be configured with app's annotations
+func (cfg Config) ValidateApp(app scalable.App) error {
+	selected, err := cfg.strategySelection().selectAppStrategy(*app.Annotations)
+	if err != nil {
+		return fmt.Errorf("could not select strategy: %w", err)
+	}
+	if _, _, err := cfg.providerSelection().selectFor(selected, *app.Annotations); err != nil {
+		return fmt.Errorf("could not select parameters providers: %w", err)
+	}
+	if _, err := parsePriority(app, cfg.AnnotationsPrefix); err != nil {
+		return err
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -75,3 +76,24 @@ func TestConfig_Validate_withDefaultParamProviders(t *testing.T) {
 	errs := config.Validate()
 	require.NotEmpty(t, errs)
 }
+
+func TestConfig_ValidateApp(t *testing.T) {
+	config := makeConfig(
+		map[parameter.Name]strategy.ParameterSpec{
+			"int": {Type: parameter.Int},
+		},
+	)
+	config.AnnotationsPrefix = "prefix/"
+	config.DefaultStrategy = "test_strategy"
+
+	app := scalable.App{Annotations: &map[string]string{"prefix/int": "1"}}
+	require.NoError(t, config.ValidateApp(app))
+
+	// Malformed parameter value
+	app = scalable.App{Annotations: &map[string]string{"prefix/int": "1.2"}}
+	require.Error(t, config.ValidateApp(app))
+
+	// Nonexistent strategy
+	app = scalable.App{Annotations: &map[string]string{"prefix/int": "1", "prefix/strategy": "nonexistent"}}
+	require.Error(t, config.ValidateApp(app))
+}
@@ -1,8 +1,10 @@
 package parameter
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -70,4 +72,37 @@ var (
 			return v, nil
 		},
 	}
+	// IntMap holds ints by string keys, its string representation is comma separated 'key=value' pairs
+	IntMap = Type{
+		Name:        "int-map",
+		ReflectType: reflect.TypeOf(map[string]int{}),
+		StrConv: func(s string) (interface{}, error) {
+			v := map[string]int{}
+			for _, pair := range strings.Split(s, ",") {
+				if pair = strings.TrimSpace(pair); len(pair) == 0 {
+					continue
+				}
+				kv := strings.SplitN(pair, "=", 2)
+				if len(kv) != 2 {
+					return nil, fmt.Errorf("'%s' is not a 'key=value' pair", pair)
+				}
+				i, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+				if err != nil {
+					return nil, err
+				}
+				v[strings.TrimSpace(kv[0])] = i
+			}
+			return v, nil
+		},
+	}
 )
+
+// TypeByName returns one of the supported types with the given name
+func TypeByName(name string) (Type, bool) {
+	for _, t := range []Type{Int, Float, String, Bool, Duration, IntMap} {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
@@ -16,6 +16,7 @@ func TestValues_Merge(t *testing.T) {
 			Strings:   map[Name]string{"string_0": "0"},
 			Booleans:  map[Name]bool{"bool_true": true},
 			Durations: map[Name]time.Duration{"duration_0": time.Duration(42)},
+			IntMaps:   map[Name]map[string]int{"int_map_0": {"a": 0}},
 		},
 		Values{
 			Ints:      map[Name]int{"int_1": 1},
@@ -23,6 +24,7 @@ func TestValues_Merge(t *testing.T) {
 			Strings:   map[Name]string{"string_1": "1"},
 			Booleans:  map[Name]bool{"bool_false": false},
 			Durations: map[Name]time.Duration{"duration_1": time.Duration(420)},
+			IntMaps:   map[Name]map[string]int{"int_map_1": {"b": 1}},
 		}
 	merged := pv0.Merge(pv1)
 	require.Equal(t,
@@ -32,6 +34,7 @@ func TestValues_Merge(t *testing.T) {
 			Strings:   map[Name]string{"string_0": "0", "string_1": "1"},
 			Booleans:  map[Name]bool{"bool_true": true, "bool_false": false},
 			Durations: map[Name]time.Duration{"duration_0": time.Duration(42), "duration_1": time.Duration(420)},
+			IntMaps:   map[Name]map[string]int{"int_map_0": {"a": 0}, "int_map_1": {"b": 1}},
 		},
 		merged,
 		"Expected values to be properly merged",
@@ -45,6 +48,7 @@ func TestValues_Insert(t *testing.T) {
 		Strings:   map[Name]string{"string_0": "0"},
 		Booleans:  map[Name]bool{"bool_true": true},
 		Durations: map[Name]time.Duration{"duration_0": time.Duration(42)},
+		IntMaps:   map[Name]map[string]int{"int_map_0": {"a": 0}},
 	}
 	cases := []struct {
 		name Name
@@ -56,6 +60,7 @@ func TestValues_Insert(t *testing.T) {
 		{"string_1", "1", String},
 		{"bool_false", false, Bool},
 		{"duration_1", time.Duration(420), Duration},
+		{"int_map_1", map[string]int{"b": 1}, IntMap},
 	}
 	for _, c := range cases {
 		err := v.Insert(c.name, c.v, c.t)
@@ -68,6 +73,7 @@ func TestValues_Insert(t *testing.T) {
 			Strings:   map[Name]string{"string_0": "0", "string_1": "1"},
 			Booleans:  map[Name]bool{"bool_true": true, "bool_false": false},
 			Durations: map[Name]time.Duration{"duration_0": time.Duration(42), "duration_1": time.Duration(420)},
+			IntMaps:   map[Name]map[string]int{"int_map_0": {"a": 0}, "int_map_1": {"b": 1}},
 		},
 		v,
 	)
@@ -95,6 +101,10 @@ func TestStrConversions(t *testing.T) {
 		{t: Float, s: "-1234", expected: -1234.},
 		{t: Float, s: "str", fail: true},
 		{t: String, s: "str", expected: "str"},
+		{t: IntMap, s: "a=1, b=-2", expected: map[string]int{"a": 1, "b": -2}},
+		{t: IntMap, s: "", expected: map[string]int{}},
+		{t: IntMap, s: "a", fail: true},
+		{t: IntMap, s: "a=b", fail: true},
 	}
 
 	trueStrings := []string{"1", "t", "T", "TRUE", "true", "True"}
@@ -126,3 +136,13 @@ func TestStrConversions(t *testing.T) {
 		require.Equal(t, tc.expected, v, msgPrefix)
 	}
 }
+
+func TestTypeByName(t *testing.T) {
+	for _, expected := range []Type{Int, Float, String, Bool, Duration} {
+		found, ok := TypeByName(expected.Name)
+		require.True(t, ok)
+		require.True(t, expected.EqualTo(found))
+	}
+	_, ok := TypeByName("complex")
+	require.False(t, ok)
+}
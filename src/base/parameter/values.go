@@ -13,6 +13,7 @@ type Values struct {
 	Strings   map[Name]string
 	Booleans  map[Name]bool
 	Durations map[Name]time.Duration
+	IntMaps   map[Name]map[string]int
 }
 
 func EmptyValues() Values {
@@ -22,6 +23,7 @@ func EmptyValues() Values {
 		Strings:   map[Name]string{},
 		Booleans:  map[Name]bool{},
 		Durations: map[Name]time.Duration{},
+		IntMaps:   map[Name]map[string]int{},
 	}
 }
 
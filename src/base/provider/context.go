@@ -1,10 +1,12 @@
 package provider
 
 import (
+	"context"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
 	"k8s.io/klog"
 	"sync"
+	"time"
 )
 
 type AppContext struct {
@@ -53,6 +55,34 @@ func (ctx AppContext) IsCanceled() bool {
 	return ctx.isClosed()
 }
 
+// Done returns a channel that's closed when the context is finished or canceled
+func (ctx AppContext) Done() <-chan struct{} {
+	return ctx.done
+}
+
+// SharedContext returns the context of a lookup shared by the apps,
+// it's canceled after the timeout or when all apps contexts are done
+func SharedContext(timeout time.Duration, appsCtx map[scalable.App]AppContext) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	go func() {
+		for _, appCtx := range appsCtx {
+			select {
+			case <-appCtx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+	return ctx, cancel
+}
+
 func (ctx AppContext) Finish() {
 	if klog.V(3) {
 		klog.Infof("Finishing app context for '%s' app and '%s' provider", ctx.App.Name, ctx.ProviderName)
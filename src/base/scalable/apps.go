@@ -1,6 +1,8 @@
 package scalable
 
 import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
 	"github.com/medal-labs/k8s-rmq-autoscaler/common"
 	"time"
 )
@@ -10,10 +12,27 @@ type App struct {
 	Ref           interface{}
 	Annotations   *map[string]string
 	Key           string
+	Namespace     string
 	Name          string
 	ReadyReplicas int
 	Replicas      int
 	UpdatedDate   time.Time
+	// Rollout contains progress of app's rollout
+	Rollout RolloutStatus
+	// Pods contains statuses of app's pods, provided by the loop when some of them are not ready
+	Pods *[]PodStatus
+	// Quota limits app's replicas number by namespace's resource quotas, nil if there is no limit
+	Quota *QuotaLimit
+	// State is provided by the executor and keeps app's values between scaling rounds
+	State state.AppState
+	// Deferred is provided by the executor and looks up app's deferred parameters
+	Deferred DeferredParameters
+}
+
+// DeferredParameters looks up parameters that are collected only when strategies need them.
+// Values of parameters that aren't provided by any provider for the app are omitted
+type DeferredParameters interface {
+	Lookup(names ...parameter.Name) (parameter.Values, error)
 }
 
 type AppId = string
@@ -0,0 +1,68 @@
+package scalable
+
+// RolloutStatus contains progress of app's rollout
+type RolloutStatus struct {
+	Generation          int64
+	ObservedGeneration  int64
+	UpdatedReplicas     int
+	UnavailableReplicas int
+}
+
+// RollingOut reports whether app's spec changes are not rolled out to all replicas yet
+func (app App) RollingOut() bool {
+	return app.Rollout.ObservedGeneration < app.Rollout.Generation || app.Rollout.UpdatedReplicas < app.Replicas
+}
+
+// PodStatus contains status of app's pod
+type PodStatus struct {
+	Name  string
+	Ready bool
+	// Unschedulable pod is pending as it can't be scheduled to any node
+	Unschedulable bool
+	// CrashLooping pod has containers waiting to restart after repeated failures
+	CrashLooping bool
+	// Restarts is the total number of pod's containers restarts
+	Restarts int
+}
+
+// PodCounts contains numbers of app's pods that are not ready by reason
+type PodCounts struct {
+	Unschedulable int
+	// Starting pods are expected to become ready
+	Starting     int
+	CrashLooping int
+}
+
+// CountPods counts app's pods that are not ready by reason.
+// Pods restarted at least crashLoopRestarts times are considered crash-looping unless crashLoopRestarts is zero.
+// Pods are considered starting if their statuses are unknown
+func (app App) CountPods(crashLoopRestarts int) PodCounts {
+	var counts PodCounts
+	if app.Pods == nil {
+		if app.ReadyReplicas < app.Replicas {
+			counts.Starting = app.Replicas - app.ReadyReplicas
+		}
+		return counts
+	}
+	for _, pod := range *app.Pods {
+		switch {
+		case pod.Ready:
+		case pod.Unschedulable:
+			counts.Unschedulable++
+		case pod.CrashLooping || (crashLoopRestarts > 0 && pod.Restarts >= crashLoopRestarts):
+			counts.CrashLooping++
+		default:
+			counts.Starting++
+		}
+	}
+	return counts
+}
+
+// QuotaLimit is the maximum number of app's replicas that fit into a resource quota
+type QuotaLimit struct {
+	MaxReplicas int
+	// Quota is the name of the most limiting resource quota
+	Quota string
+	// Resource is the name of the quota's most limiting resource
+	Resource string
+}
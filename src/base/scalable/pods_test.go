@@ -0,0 +1,36 @@
+package scalable
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestApp_CountPods(t *testing.T) {
+	app := App{Replicas: 3, ReadyReplicas: 1}
+	require.Equal(t, PodCounts{Starting: 2}, app.CountPods(0))
+
+	app.Pods = &[]PodStatus{
+		{Name: "ready", Ready: true, Restarts: 5},
+		{Name: "pending", Unschedulable: true},
+		{Name: "crash-looping", CrashLooping: true},
+		{Name: "restarted", Restarts: 3},
+		{Name: "starting"},
+	}
+	require.Equal(t, PodCounts{Unschedulable: 1, Starting: 2, CrashLooping: 1}, app.CountPods(0))
+	require.Equal(t, PodCounts{Unschedulable: 1, Starting: 1, CrashLooping: 2}, app.CountPods(3))
+}
+
+func TestApp_RollingOut(t *testing.T) {
+	app := App{
+		Replicas: 2,
+		Rollout:  RolloutStatus{Generation: 2, ObservedGeneration: 2, UpdatedReplicas: 2},
+	}
+	require.False(t, app.RollingOut())
+
+	app.Rollout.Generation = 3
+	require.True(t, app.RollingOut())
+
+	app.Rollout.ObservedGeneration = 3
+	app.Rollout.UpdatedReplicas = 1
+	require.True(t, app.RollingOut())
+}
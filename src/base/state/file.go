@@ -0,0 +1,78 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a MemoryStore that restores its values from a JSON file on creation
+// and writes them back on Flush, so that apps' state survives restarts
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		path:        path,
+	}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if len(content) == 0 {
+		return store, nil
+	}
+	if err := json.Unmarshal(content, &store.apps); err != nil {
+		return nil, fmt.Errorf("failed to decode state file: %w", err)
+	}
+	return store, nil
+}
+
+// Flush writes values to the file if they were changed since the last flush
+func (s *FileStore) Flush() error {
+	s.mx.Lock()
+	if !s.dirty {
+		s.mx.Unlock()
+		return nil
+	}
+	content, err := json.Marshal(s.apps)
+	s.dirty = false
+	s.mx.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := s.write(content); err != nil {
+		s.mx.Lock()
+		s.dirty = true
+		s.mx.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) write(content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrNotConfigured = errors.New("state store is not configured")
+
+// Store keeps values associated with apps between executor rounds. Values are stored in serialized form,
+// so they can be persisted by store implementations
+type Store interface {
+	Get(appKey string, name string, v interface{}) (bool, error)
+	Set(appKey string, name string, v interface{}) error
+	Delete(appKey string)
+}
+
+// Flusher is implemented by stores that are able to persist their state
+type Flusher interface {
+	Flush() error
+}
+
+// AppState is a view of the Store limited to the values of a single app
+type AppState struct {
+	store Store
+	key   string
+}
+
+func For(store Store, appKey string) AppState {
+	return AppState{store: store, key: appKey}
+}
+
+func (s AppState) Get(name string, v interface{}) (bool, error) {
+	if s.store == nil {
+		return false, ErrNotConfigured
+	}
+	return s.store.Get(s.key, name, v)
+}
+
+func (s AppState) Set(name string, v interface{}) error {
+	if s.store == nil {
+		return ErrNotConfigured
+	}
+	return s.store.Set(s.key, name, v)
+}
+
+type MemoryStore struct {
+	mx   sync.Mutex
+	apps map[string]map[string]json.RawMessage
+	// dirty is set when values are changed, it's used by stores persisting their state
+	dirty bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{apps: map[string]map[string]json.RawMessage{}}
+}
+
+func (s *MemoryStore) Get(appKey string, name string, v interface{}) (bool, error) {
+	s.mx.Lock()
+	raw, ok := s.apps[appKey][name]
+	s.mx.Unlock()
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to decode '%s' state of '%s' app: %w", name, appKey, err)
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) Set(appKey string, name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode '%s' state of '%s' app: %w", name, appKey, err)
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	values, ok := s.apps[appKey]
+	if !ok {
+		values = map[string]json.RawMessage{}
+		s.apps[appKey] = values
+	}
+	if !bytes.Equal(values[name], raw) {
+		values[name] = raw
+		s.dirty = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(appKey string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if _, ok := s.apps[appKey]; ok {
+		delete(s.apps, appKey)
+		s.dirty = true
+	}
+}
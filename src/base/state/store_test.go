@@ -0,0 +1,94 @@
+package state
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testValue struct {
+	Int  int
+	Time time.Time
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	appState := For(store, "ns/app")
+
+	var v testValue
+	found, err := appState.Get("value", &v)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	expected := testValue{Int: 42, Time: time.Unix(42, 0).UTC()}
+	require.NoError(t, appState.Set("value", expected))
+
+	found, err = appState.Get("value", &v)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, expected, v)
+
+	// Values of other apps are not visible
+	found, err = For(store, "ns/other-app").Get("value", &v)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	store.Delete("ns/app")
+	found, err = appState.Get("value", &v)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestAppState_withoutStore(t *testing.T) {
+	var v testValue
+	_, err := AppState{}.Get("value", &v)
+	require.ErrorIs(t, err, ErrNotConfigured)
+	require.ErrorIs(t, AppState{}.Set("value", v), ErrNotConfigured)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	expected := testValue{Int: 42, Time: time.Unix(42, 0).UTC()}
+	require.NoError(t, For(store, "ns/app").Set("value", expected))
+	require.NoError(t, store.Flush())
+
+	restored, err := NewFileStore(path)
+	require.NoError(t, err)
+	var v testValue
+	found, err := For(restored, "ns/app").Get("value", &v)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, expected, v)
+}
+
+func TestFileStore_flushChangesOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Flush())
+	require.NoFileExists(t, path, "Expected unchanged store not to be written")
+
+	require.NoError(t, For(store, "ns/app").Set("value", 1))
+	require.NoError(t, store.Flush())
+	require.FileExists(t, path)
+
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, For(store, "ns/app").Set("value", 1))
+	require.NoError(t, store.Flush())
+	require.NoFileExists(t, path, "Expected store not to be written when the same value is set")
+
+	require.NoError(t, For(store, "ns/app").Set("value", 2))
+	require.NoError(t, store.Flush())
+	require.FileExists(t, path)
+
+	require.NoError(t, os.Remove(path))
+	store.Delete("ns/app")
+	require.NoError(t, store.Flush())
+	require.FileExists(t, path, "Expected store to be written after app's values are deleted")
+}
@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"errors"
 	"fmt"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"reflect"
@@ -11,6 +12,9 @@ type RequiredParameters map[parameter.Name]ParameterSpec
 type ParameterSpec struct {
 	DefaultValue interface{}
 	Type         parameter.Type
+	// Deferred parameters aren't collected before the strategy is executed, their default values are used
+	// unless modifiers look them up with app's Deferred when they need them, e.g. only for scaling down
+	Deferred bool
 }
 
 func (p RequiredParameters) Validate() error {
@@ -24,6 +28,9 @@ func (p RequiredParameters) Validate() error {
 
 func (cfg ParameterSpec) Validate() error {
 	if cfg.DefaultValue == nil {
+		if cfg.Deferred {
+			return errors.New("deferred parameter must have a default value")
+		}
 		return nil
 	}
 	defaultValue := cfg.DefaultValue
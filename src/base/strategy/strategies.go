@@ -16,12 +16,30 @@ type Config struct {
 	RequiredParameters RequiredParameters
 	ResultModifiers    []ResultModifier
 	Execute            func(app scalable.App, params parameter.Values) (Result, error)
+	// Build is an optional hook used by strategies that depend on app's annotations.
+	// Config returned by Build is used for the app instead of the selected one
+	Build func(cfg Config, ctx BuildContext) (Config, error)
+}
+
+// BuildContext contains information available to strategies when they are built for an app
+type BuildContext struct {
+	AnnotationsPrefix string
+	Annotations       map[string]string
+	Strategies        map[YAMLName]Config
+	// Parameters contains types of all parameters available from enabled providers
+	Parameters map[parameter.Name]parameter.Type
 }
 
 type Result struct {
 	App              scalable.App
 	RequiredReplicas int
 	Skip             bool
+	SkipReason       string
+	// PodDeletionCosts are set on app's pods by their IPs before scaling down,
+	// pods with lower cost are removed first
+	PodDeletionCosts map[string]int
+	// Warnings are reported for the app regardless of whether scaling is skipped
+	Warnings []string
 }
 
 type ResultModifier struct {
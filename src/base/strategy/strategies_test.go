@@ -26,6 +26,7 @@ var incorrectParams = []RequiredParameters{
 	{"int": {Type: parameter.Int, DefaultValue: "42"}},
 	{"float": {Type: parameter.Float, DefaultValue: "42"}},
 	{"string": {Type: parameter.String, DefaultValue: 4.2}},
+	{"deferred": {Type: parameter.Int, Deferred: true}},
 }
 
 func Test_Ready(t *testing.T) {
@@ -0,0 +1,14 @@
+package common
+
+import "strings"
+
+// SplitList splits comma separated list, items are trimmed and empty items are omitted
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	testCases := []struct {
+		name     string
+		list     string
+		expected []string
+	}{
+		{name: "empty", list: "", expected: nil},
+		{name: "only separators", list: " , ,", expected: nil},
+		{name: "single item", list: "first", expected: []string{"first"}},
+		{name: "trimmed items", list: " first ,second,, third ", expected: []string{"first", "second", "third"}},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, SplitList(tc.list), tc.name)
+	}
+}
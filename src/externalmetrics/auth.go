@@ -0,0 +1,161 @@
+package externalmetrics
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"net/http"
+	"strings"
+)
+
+const (
+	authenticationConfigMapNamespace = "kube-system"
+	authenticationConfigMapName      = "extension-apiserver-authentication"
+
+	requestHeaderClientCAKey       = "requestheader-client-ca-file"
+	requestHeaderAllowedNamesKey   = "requestheader-allowed-names"
+	requestHeaderUsernameHeaderKey = "requestheader-username-headers"
+	requestHeaderGroupHeadersKey   = "requestheader-group-headers"
+)
+
+// delegatedAuth authenticates and authorizes requests with the cluster's API server, the same way
+// aggregated API servers do. Requests proxied by the API server are authenticated by its client
+// certificate and carry the user in headers, other requests are authenticated by bearer tokens
+type delegatedAuth struct {
+	client kubernetes.Interface
+
+	clientCAs       *x509.CertPool
+	allowedNames    map[string]bool
+	usernameHeaders []string
+	groupHeaders    []string
+}
+
+type user struct {
+	name   string
+	groups []string
+}
+
+// newDelegatedAuth reads the front proxy configuration published by the API server
+func newDelegatedAuth(ctx context.Context, client kubernetes.Interface) (*delegatedAuth, error) {
+	configMap, err := client.CoreV1().ConfigMaps(authenticationConfigMapNamespace).Get(
+		ctx, authenticationConfigMapName, metav1.GetOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s config map: %w", authenticationConfigMapNamespace, authenticationConfigMapName, err)
+	}
+	auth := &delegatedAuth{
+		client:       client,
+		clientCAs:    x509.NewCertPool(),
+		allowedNames: map[string]bool{},
+	}
+	ca, ok := configMap.Data[requestHeaderClientCAKey]
+	if !ok || !auth.clientCAs.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("%s config map doesn't contain valid '%s'", authenticationConfigMapName, requestHeaderClientCAKey)
+	}
+	var allowedNames []string
+	if err := unmarshalList(configMap.Data[requestHeaderAllowedNamesKey], &allowedNames); err != nil {
+		return nil, fmt.Errorf("malformed '%s': %w", requestHeaderAllowedNamesKey, err)
+	}
+	for _, name := range allowedNames {
+		auth.allowedNames[name] = true
+	}
+	if err := unmarshalList(configMap.Data[requestHeaderUsernameHeaderKey], &auth.usernameHeaders); err != nil {
+		return nil, fmt.Errorf("malformed '%s': %w", requestHeaderUsernameHeaderKey, err)
+	}
+	if err := unmarshalList(configMap.Data[requestHeaderGroupHeadersKey], &auth.groupHeaders); err != nil {
+		return nil, fmt.Errorf("malformed '%s': %w", requestHeaderGroupHeadersKey, err)
+	}
+	return auth, nil
+}
+
+func unmarshalList(data string, list *[]string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), list)
+}
+
+// authenticate returns the user of the request, nil user means the request isn't authenticated
+func (a *delegatedAuth) authenticate(r *http.Request) (*user, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return a.authenticateProxied(r), nil
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if len(token) == 0 || token == r.Header.Get("Authorization") {
+		return nil, nil
+	}
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, nil
+	}
+	return &user{name: review.Status.User.Username, groups: review.Status.User.Groups}, nil
+}
+
+// authenticateProxied trusts user headers only when the client certificate is the front proxy's one
+func (a *delegatedAuth) authenticateProxied(r *http.Request) *user {
+	commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if len(a.allowedNames) > 0 && !a.allowedNames[commonName] {
+		return nil
+	}
+	u := &user{}
+	for _, header := range a.usernameHeaders {
+		if name := r.Header.Get(header); len(name) > 0 {
+			u.name = name
+			break
+		}
+	}
+	if len(u.name) == 0 {
+		return nil
+	}
+	for _, header := range a.groupHeaders {
+		u.groups = append(u.groups, r.Header.Values(header)...)
+	}
+	return u
+}
+
+// authorize checks that the user can get the metric in the namespace
+func (a *delegatedAuth) authorize(ctx context.Context, u *user, namespace string, metric string) (bool, error) {
+	review, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   u.name,
+			Groups: u.groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Group:     group,
+				Version:   version,
+				Resource:  metric,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("subject access review failed: %w", err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// authenticated rejects requests without authenticated user
+func (a *delegatedAuth) authenticated(handler func(http.ResponseWriter, *http.Request, *user)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, err := a.authenticate(r)
+		if err != nil {
+			writeStatus(w, http.StatusInternalServerError, "InternalError", err.Error())
+			return
+		}
+		if u == nil {
+			writeStatus(w, http.StatusUnauthorized, "Unauthorized", "request is not authenticated")
+			return
+		}
+		handler(w, r, u)
+	}
+}
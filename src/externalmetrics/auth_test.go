@@ -0,0 +1,70 @@
+package externalmetrics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	auth := &delegatedAuth{
+		allowedNames:    map[string]bool{"front-proxy-client": true},
+		usernameHeaders: []string{"X-Remote-User"},
+		groupHeaders:    []string{"X-Remote-Group"},
+	}
+	verified := func(commonName string) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}},
+		}
+	}
+	testCases := []struct {
+		name     string
+		tls      *tls.ConnectionState
+		headers  map[string][]string
+		expected *user
+	}{
+		{
+			name: "proxied request",
+			tls:  verified("front-proxy-client"),
+			headers: map[string][]string{
+				"X-Remote-User":  {"system:serviceaccount:kube-system:horizontal-pod-autoscaler"},
+				"X-Remote-Group": {"system:serviceaccounts", "system:authenticated"},
+			},
+			expected: &user{
+				name:   "system:serviceaccount:kube-system:horizontal-pod-autoscaler",
+				groups: []string{"system:serviceaccounts", "system:authenticated"},
+			},
+		},
+		{
+			name:    "not allowed proxy name",
+			tls:     verified("someone"),
+			headers: map[string][]string{"X-Remote-User": {"admin"}},
+		},
+		{
+			name: "proxied request without user",
+			tls:  verified("front-proxy-client"),
+		},
+		{
+			name:    "user headers without client certificate",
+			headers: map[string][]string{"X-Remote-User": {"admin"}},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, groupPrefix, nil)
+			r.TLS = testCase.tls
+			for header, values := range testCase.headers {
+				for _, value := range values {
+					r.Header.Add(header, value)
+				}
+			}
+			u, err := auth.authenticate(r)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, u)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package externalmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	VhostLabel = "vhost"
+	QueueLabel = "queue"
+
+	DefaultVhost = "%2F"
+)
+
+type Config struct {
+	Address  string
+	CertFile string
+	KeyFile  string
+	// Client is used to delegate authentication and authorization of requests to the API server
+	Client kubernetes.Interface
+	// Provider provides metrics values for queues selected by 'queue' and 'vhost' annotations
+	Provider          provider.Config
+	AnnotationsPrefix string
+	CacheTTL          time.Duration
+	Timeout           time.Duration
+}
+
+type server struct {
+	config  Config
+	metrics map[string]parameter.Type
+	auth    *delegatedAuth
+
+	mx    sync.Mutex
+	cache map[string]cachedValues
+}
+
+type cachedValues struct {
+	values provider.ProvidedParameters
+	time   time.Time
+}
+
+// Serve exposes numeric parameters of the provider through external metrics API until the context is done.
+// Metrics are named after the parameters and selected by 'queue' and optional 'vhost' labels.
+// An error is returned when the server can't be configured
+func Serve(ctx context.Context, config Config) error {
+	if len(config.CertFile) == 0 || len(config.KeyFile) == 0 {
+		return errors.New("external metrics API requires TLS certificate and key files")
+	}
+	certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load external metrics API certificate: %w", err)
+	}
+	auth, err := newDelegatedAuth(ctx, config.Client)
+	if err != nil {
+		return fmt.Errorf("failed to configure external metrics API authentication: %w", err)
+	}
+	s := &server{
+		config:  config,
+		metrics: map[string]parameter.Type{},
+		auth:    auth,
+		cache:   map[string]cachedValues{},
+	}
+	for name, t := range config.Provider.AvailableParameters {
+		if t.EqualTo(parameter.Int) || t.EqualTo(parameter.Float) || t.EqualTo(parameter.Duration) {
+			s.metrics[string(name)] = t
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc(groupPrefix, auth.authenticated(s.handleDiscovery))
+	mux.HandleFunc(groupPrefix+"/namespaces/", auth.authenticated(s.handleMetric))
+	httpServer := &http.Server{
+		Addr:    config.Address,
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    auth.clientCAs,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		klog.Infof("Serving external metrics API on %s", config.Address)
+		err := httpServer.ListenAndServeTLS("", "")
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("External metrics API server failed: %s", err)
+		}
+	}()
+	return nil
+}
+
+func (s *server) handleDiscovery(w http.ResponseWriter, r *http.Request, _ *user) {
+	names := make([]string, 0, len(s.metrics))
+	for name := range s.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := apiResourceList{
+		typeMeta:     typeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: group + "/" + version,
+		Resources:    []apiResource{},
+	}
+	for _, name := range names {
+		list.Resources = append(list.Resources, apiResource{
+			Name:       name,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      []string{"get"},
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleMetric serves '/namespaces/{namespace}/{metric}' requests
+func (s *server) handleMetric(w http.ResponseWriter, r *http.Request, u *user) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, groupPrefix+"/namespaces/"), "/")
+	if len(path) != 2 {
+		writeStatus(w, http.StatusNotFound, "NotFound", fmt.Sprintf("unknown path '%s'", r.URL.Path))
+		return
+	}
+	namespace, metricName := path[0], path[1]
+	t, ok := s.metrics[metricName]
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NotFound", fmt.Sprintf("metric '%s' is not found", metricName))
+		return
+	}
+	allowed, err := s.auth.authorize(r.Context(), u, namespace, metricName)
+	if err != nil {
+		writeStatus(w, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	if !allowed {
+		writeStatus(w, http.StatusForbidden, "Forbidden", fmt.Sprintf(
+			"user '%s' can't get '%s' metric in '%s' namespace", u.name, metricName, namespace,
+		))
+		return
+	}
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", fmt.Sprintf("invalid label selector: %s", err))
+		return
+	}
+	queue, ok := selector.RequiresExactMatch(QueueLabel)
+	if !ok {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "label selector must match exact 'queue' label")
+		return
+	}
+	// Default vhost '/' can't be a label value
+	vhost, ok := selector.RequiresExactMatch(VhostLabel)
+	if !ok {
+		vhost = DefaultVhost
+	}
+	values, err := s.queueValues(namespace, vhost, queue)
+	if err != nil {
+		klog.Errorf("Failed to get external metrics for '%s' queue in '%s' vhost for '%s' namespace: %s", queue, vhost, namespace, err)
+		writeStatus(w, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	value, err := quantity(values.values[parameter.Name(metricName)], t)
+	if err != nil {
+		writeStatus(w, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, externalMetricValueList{
+		typeMeta: typeMeta{Kind: "ExternalMetricValueList", APIVersion: group + "/" + version},
+		Items: []externalMetricValue{
+			{
+				MetricName:   metricName,
+				MetricLabels: map[string]string{VhostLabel: vhost, QueueLabel: queue},
+				Timestamp:    values.time.UTC().Format(time.RFC3339),
+				Value:        value,
+			},
+		},
+	})
+}
+
+// queueValues returns cached values of all metrics for the queue requested from the namespace,
+// or requests them from the provider
+func (s *server) queueValues(namespace, vhost, queue string) (cachedValues, error) {
+	key := namespace + "/" + vhost + "/" + queue
+
+	s.mx.Lock()
+	cached, ok := s.cache[key]
+	s.mx.Unlock()
+	if ok && time.Since(cached.time) < s.config.CacheTTL {
+		return cached, nil
+	}
+	app := scalable.App{
+		Key:       key,
+		Name:      queue,
+		Namespace: namespace,
+		Annotations: &map[string]string{
+			s.config.AnnotationsPrefix + QueueLabel: queue,
+			s.config.AnnotationsPrefix + VhostLabel: vhost,
+		},
+	}
+	names := make([]parameter.Name, 0, len(s.metrics))
+	for name := range s.metrics {
+		names = append(names, parameter.Name(name))
+	}
+	ctx := provider.Launch(s.config.Provider, map[scalable.App][]parameter.Name{app: names})[app]
+
+	timeout := time.AfterFunc(s.config.Timeout, ctx.Cancel)
+	defer timeout.Stop()
+
+	values := provider.ProvidedParameters{}
+	for {
+		result, ok := ctx.GetNextResult()
+		if !ok {
+			break
+		}
+		if result.Error != nil {
+			ctx.Cancel()
+			return cachedValues{}, result.Error
+		}
+		for name, value := range result.Parameters {
+			values[name] = value
+		}
+	}
+	if len(values) < len(names) {
+		return cachedValues{}, errors.New("provider didn't provide all metrics in time")
+	}
+	cached = cachedValues{values: values, time: time.Now()}
+
+	s.mx.Lock()
+	for cachedKey, values := range s.cache {
+		if cached.time.Sub(values.time) >= s.config.CacheTTL {
+			delete(s.cache, cachedKey)
+		}
+	}
+	s.cache[key] = cached
+	s.mx.Unlock()
+	return cached, nil
+}
+
+// quantity formats parameter value of the given type as a Kubernetes quantity, durations are in seconds
+func quantity(v interface{}, t parameter.Type) (string, error) {
+	switch {
+	case t.EqualTo(parameter.Int):
+		i, ok := v.(int)
+		if ok {
+			return resource.NewQuantity(int64(i), resource.DecimalSI).String(), nil
+		}
+	case t.EqualTo(parameter.Float):
+		f, ok := v.(float64)
+		if ok {
+			return resource.NewMilliQuantity(int64(f*1000), resource.DecimalSI).String(), nil
+		}
+	case t.EqualTo(parameter.Duration):
+		d, ok := v.(time.Duration)
+		if ok {
+			return resource.NewMilliQuantity(d.Milliseconds(), resource.DecimalSI).String(), nil
+		}
+	}
+	return "", fmt.Errorf("unexpected value %v of %s type", v, t.Name)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Errorf("Failed to write external metrics API response: %s", err)
+	}
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason string, message string) {
+	writeJSON(w, code, status{
+		typeMeta: typeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   "Failure",
+		Message:  message,
+		Reason:   reason,
+		Code:     code,
+	})
+}
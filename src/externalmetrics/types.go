@@ -0,0 +1,49 @@
+package externalmetrics
+
+const (
+	group       = "external.metrics.k8s.io"
+	version     = "v1beta1"
+	groupPrefix = "/apis/" + group + "/" + version
+)
+
+// Types below mirror the ones of external metrics API, only fields used by HPA are included
+
+type typeMeta struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+}
+
+type apiResource struct {
+	Name         string   `json:"name"`
+	SingularName string   `json:"singularName"`
+	Namespaced   bool     `json:"namespaced"`
+	Kind         string   `json:"kind"`
+	Verbs        []string `json:"verbs"`
+}
+
+type apiResourceList struct {
+	typeMeta
+	GroupVersion string        `json:"groupVersion"`
+	Resources    []apiResource `json:"resources"`
+}
+
+type externalMetricValue struct {
+	MetricName   string            `json:"metricName"`
+	MetricLabels map[string]string `json:"metricLabels"`
+	Timestamp    string            `json:"timestamp"`
+	Value        string            `json:"value"`
+}
+
+type externalMetricValueList struct {
+	typeMeta
+	Metadata struct{}              `json:"metadata"`
+	Items    []externalMetricValue `json:"items"`
+}
+
+type status struct {
+	typeMeta
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Code    int    `json:"code"`
+}
@@ -5,4 +5,6 @@ const (
 	AnnotationPrefix = "k8s-rmq-autoscaler/"
 	// Enable Annotation key used to enable the scaler
 	Enable = "enable"
+	// HandoffReplicas Annotation key of the replicas number set when autoscaling of the deployment is disabled
+	HandoffReplicas = "handoff-replicas"
 )
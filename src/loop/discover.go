@@ -11,9 +11,13 @@ import (
 	"k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
@@ -37,32 +41,56 @@ func newController(queue workqueue.Interface, indexer cache.Indexer, informer ca
 	}
 }
 
-func createClient(inCluster bool) (*kubernetes.Clientset, error) {
-	var config *rest.Config
-	var err error
+func createConfig(inCluster bool) (*rest.Config, error) {
 	if inCluster {
-		config, err = rest.InClusterConfig()
-	} else {
-		kubeconfig := filepath.Join(os.Getenv("HOME"), ".kube", "config")
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		return rest.InClusterConfig()
 	}
+	kubeconfig := filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
+
+// NewClient creates a Kubernetes client configured the same way as the loop's one
+func NewClient(inCluster bool) (kubernetes.Interface, error) {
+	config, err := createConfig(inCluster)
 	if err != nil {
 		return nil, err
 	}
-	client, err := kubernetes.NewForConfig(config)
+	return kubernetes.NewForConfig(config)
+}
+
+var deploymentsResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+// discover watches deployments matching the label selector, with metadataOnly only metadata of deployments is cached
+// and the deployments concerned by autoscaling are fetched by the loop
+func discover(ctx context.Context, hub *AutoscalerLoop, inCluster bool, namespacesToWatch string, selector string, metadataOnly bool, importKEDA bool) (*kubernetes.Clientset, error) {
+	config, err := createConfig(inCluster)
 	if err != nil {
 		return nil, err
 	}
-	return client, nil
-}
-
-func discover(ctx context.Context, hub *AutoscalerLoop, inCluster bool, namespacesToWatch string) (*kubernetes.Clientset, error) {
 	// create the clientset
-	client, err := createClient(inCluster)
-
+	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
+	var metadataClient metadata.Interface
+	if metadataOnly {
+		if metadataClient, err = metadata.NewForConfig(config); err != nil {
+			return nil, err
+		}
+	}
+	var dynamicClient dynamic.Interface
+	if importKEDA {
+		installed, err := scaledObjectsInstalled(client.Discovery())
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover KEDA ScaledObjects: %w", err)
+		}
+		if !installed {
+			klog.Warningf("KEDA ScaledObjects are not served by the API server, they won't be imported")
+			importKEDA = false
+		} else if dynamicClient, err = dynamic.NewForConfig(config); err != nil {
+			return nil, err
+		}
+	}
 
 	namespaceToWatch := getNamespacesSet(namespacesToWatch)
 
@@ -71,6 +99,7 @@ func discover(ctx context.Context, hub *AutoscalerLoop, inCluster bool, namespac
 		return nil, err
 	}
 
+	var factories []informers.SharedInformerFactory
 	for _, namespace := range namespaces.Items {
 		klog.Infof("Scanning namespace %s", namespace.Name)
 
@@ -82,10 +111,18 @@ func discover(ctx context.Context, hub *AutoscalerLoop, inCluster bool, namespac
 			}
 		}
 
-		listWatch := createWatch(ctx, client, namespace.Name)
+		var listWatch *cache.ListWatch
+		var objType runtime.Object
+		if metadataOnly {
+			listWatch = createMetadataWatch(ctx, metadataClient, namespace.Name, selector)
+			objType = &metav1.PartialObjectMetadata{}
+		} else {
+			listWatch = createWatch(ctx, client, namespace.Name, selector)
+			objType = &v1.Deployment{}
+		}
 		queue := workqueue.New()
 
-		indexer, informer := cache.NewIndexerInformer(listWatch, &v1.Deployment{}, 0, cache.ResourceEventHandlerFuncs{
+		indexer, informer := cache.NewIndexerInformer(listWatch, objType, 0, cache.ResourceEventHandlerFuncs{
 			AddFunc: func(o interface{}) {
 				key, err := cache.MetaNamespaceKeyFunc(o)
 				if err == nil {
@@ -109,22 +146,51 @@ func discover(ctx context.Context, hub *AutoscalerLoop, inCluster bool, namespac
 		controller := newController(queue, indexer, informer, hub)
 
 		go controller.run(ctx)
+
+		// Resource quotas are cached, so that scaling rounds don't wait for API server
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace.Name))
+		hub.quotas[namespace.Name] = factory.Core().V1().ResourceQuotas().Lister().ResourceQuotas(namespace.Name)
+		factory.Start(ctx.Done())
+		factories = append(factories, factory)
+
+		if importKEDA {
+			watchScaledObjects(ctx, dynamicClient, namespace.Name, hub.scaledObjects)
+		}
+	}
+	// Caches of all namespaces are synced at once
+	for _, factory := range factories {
+		factory.WaitForCacheSync(ctx.Done())
 	}
 
 	return client, nil
 }
 
-func createWatch(ctx context.Context, client *kubernetes.Clientset, namespace string) *cache.ListWatch {
+func createWatch(ctx context.Context, client *kubernetes.Clientset, namespace string, selector string) *cache.ListWatch {
 	return &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector
 			return client.AppsV1().Deployments(namespace).List(ctx, options)
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector
 			return client.AppsV1().Deployments(namespace).Watch(ctx, options)
 		},
 	}
 }
 
+func createMetadataWatch(ctx context.Context, client metadata.Interface, namespace string, selector string) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector
+			return client.Resource(deploymentsResource).Namespace(namespace).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector
+			return client.Resource(deploymentsResource).Namespace(namespace).Watch(ctx, options)
+		},
+	}
+}
+
 func getNamespacesSet(namespacesToWatch string) map[string]bool {
 	namespaceToWatchSet := make(map[string]bool)
 	for _, namespacesToWatch := range strings.Split(namespacesToWatch, ",") {
@@ -171,16 +237,19 @@ func (c *controller) processNextItem() bool {
 		return true
 	}
 
-	if obj == nil {
-		klog.Errorf("Object is nil %s", key)
+	if !exists {
+		klog.Infof("Deployment %s does not exist anymore", key)
+		c.hub.delete <- key.(string)
 		return true
 	}
 
-	if !exists {
-		fmt.Printf("Deployment %s does not exist anymore\n", key)
-		c.hub.delete <- obj.(*v1.Deployment)
-	} else {
-		c.hub.add <- obj.(*v1.Deployment)
+	switch o := obj.(type) {
+	case *v1.Deployment:
+		c.hub.add <- o
+	case *metav1.PartialObjectMetadata:
+		c.hub.metadata <- o
+	default:
+		klog.Errorf("Object %s has unexpected type %T", key, obj)
 	}
 	return true
 }
@@ -0,0 +1,112 @@
+package loop
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events emitted by the autoscaler
+const (
+	ReasonScaleUp       = "ASScaleUp"
+	ReasonScaleDown     = "ASScaleDown"
+	ReasonSkip          = "ASSkip"
+	ReasonWarning       = "ASWarning"
+	ReasonInvalidConfig = "ASInvalidConfig"
+	ReasonScalingError  = "ASScalingError"
+	ReasonImportError   = "ASImportError"
+	ReasonHandoff       = "ASHandoff"
+)
+
+const (
+	// eventsMinInterval limits how often events with the same reason are emitted for an object
+	eventsMinInterval = time.Minute
+	// eventsRepeatInterval is the interval after which an unchanged event is emitted again
+	eventsRepeatInterval = 30 * time.Minute
+)
+
+// eventAggregator emits events only when their message for the object and reason changes,
+// and rate limits them by object and reason.
+// Suppressed events are counted and the count is included in the next emitted event
+type eventAggregator struct {
+	recorder record.EventRecorder
+	now      func() time.Time
+
+	mx      sync.Mutex
+	objects map[string]*objectEvents
+}
+
+type objectEvents struct {
+	reasons map[string]*reasonEvents
+}
+
+type reasonEvents struct {
+	// lastMessage is the message of the last recorded event, a suppressed change is recorded once the interval has passed
+	lastMessage string
+	emitted     time.Time
+	suppressed  int
+}
+
+func newEventAggregator(recorder record.EventRecorder) *eventAggregator {
+	return &eventAggregator{
+		recorder: recorder,
+		now:      time.Now,
+		objects:  map[string]*objectEvents{},
+	}
+}
+
+// emit records the event for the object with the given key unless it's suppressed
+func (a *eventAggregator) emit(obj runtime.Object, key string, eventType string, reason string, message string) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	objEvents, ok := a.objects[key]
+	if !ok {
+		objEvents = &objectEvents{reasons: map[string]*reasonEvents{}}
+		a.objects[key] = objEvents
+	}
+	events, ok := objEvents.reasons[reason]
+	if !ok {
+		events = &reasonEvents{}
+		objEvents.reasons[reason] = events
+	}
+	now := a.now()
+	sinceEmitted := now.Sub(events.emitted)
+	changed := events.lastMessage != message
+
+	if !(changed && sinceEmitted >= eventsMinInterval) && sinceEmitted < eventsRepeatInterval {
+		events.suppressed++
+		return
+	}
+	events.lastMessage = message
+	events.emitted = now
+	if events.suppressed > 0 {
+		message = fmt.Sprintf("%s (%d similar events suppressed)", message, events.suppressed)
+	}
+	events.suppressed = 0
+	a.recorder.Event(obj, eventType, reason, message)
+}
+
+// emitAlways records the event regardless of previous events, it's used for events of actions performed by the autoscaler.
+// The next events of the object are considered as state changes
+func (a *eventAggregator) emitAlways(obj runtime.Object, key string, eventType string, reason string, message string) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	if objEvents, ok := a.objects[key]; ok {
+		for _, events := range objEvents.reasons {
+			events.lastMessage = ""
+		}
+	}
+	a.recorder.Event(obj, eventType, reason, message)
+}
+
+// forget removes events history of the object
+func (a *eventAggregator) forget(key string) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	delete(a.objects, key)
+}
@@ -0,0 +1,91 @@
+package loop
+
+import (
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"testing"
+	"time"
+)
+
+type emitted struct {
+	after   time.Duration
+	message string
+}
+
+func TestEventAggregator(t *testing.T) {
+	testCases := []struct {
+		name     string
+		emitted  []emitted
+		expected []string
+	}{
+		{
+			name:     "unchanged messages are deduplicated",
+			emitted:  []emitted{{0, "a"}, {10 * time.Second, "a"}, {2 * time.Minute, "a"}},
+			expected: []string{"a"},
+		},
+		{
+			name:     "unchanged message is repeated after the interval",
+			emitted:  []emitted{{0, "a"}, {10 * time.Second, "a"}, {eventsRepeatInterval, "a"}},
+			expected: []string{"a", "a (1 similar events suppressed)"},
+		},
+		{
+			name:     "changed message is emitted",
+			emitted:  []emitted{{0, "a"}, {eventsMinInterval, "b"}},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "changes are rate limited",
+			emitted:  []emitted{{0, "a"}, {10 * time.Second, "b"}, {10 * time.Second, "a"}},
+			expected: []string{"a"},
+		},
+		{
+			name:     "suppressed change is emitted after the interval",
+			emitted:  []emitted{{0, "a"}, {10 * time.Second, "b"}, {10 * time.Second, "b"}, {eventsMinInterval, "b"}},
+			expected: []string{"a", "b (2 similar events suppressed)"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(len(testCase.emitted))
+			aggregator := newEventAggregator(recorder)
+			now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+			aggregator.now = func() time.Time { return now }
+
+			for _, event := range testCase.emitted {
+				now = now.Add(event.after)
+				aggregator.emit(nil, "default/app", corev1.EventTypeWarning, ReasonWarning, event.message)
+			}
+			close(recorder.Events)
+			recorded := []string{}
+			for event := range recorder.Events {
+				recorded = append(recorded, event)
+			}
+			expected := []string{}
+			for _, message := range testCase.expected {
+				expected = append(expected, corev1.EventTypeWarning+" "+ReasonWarning+" "+message)
+			}
+			require.Equal(t, expected, recorded)
+		})
+	}
+}
+
+func TestEventAggregator_emitAlways(t *testing.T) {
+	recorder := record.NewFakeRecorder(3)
+	aggregator := newEventAggregator(recorder)
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	aggregator.now = func() time.Time { return now }
+
+	aggregator.emit(nil, "default/app", corev1.EventTypeNormal, ReasonSkip, "skipped")
+	now = now.Add(10 * time.Second)
+	aggregator.emitAlways(nil, "default/app", corev1.EventTypeNormal, ReasonScaleUp, "scaled up")
+	now = now.Add(eventsMinInterval)
+	aggregator.emit(nil, "default/app", corev1.EventTypeNormal, ReasonSkip, "skipped")
+	close(recorder.Events)
+
+	recorded := []string{}
+	for event := range recorder.Events {
+		recorded = append(recorded, event)
+	}
+	require.Equal(t, []string{"Normal ASSkip skipped", "Normal ASScaleUp scaled up", "Normal ASSkip skipped"}, recorded)
+}
@@ -0,0 +1,296 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+var scaledObjectsResource = schema.GroupVersionResource{Group: "keda.sh", Version: "v1alpha1", Resource: "scaledobjects"}
+
+// Defaults of KEDA ScaledObject and rabbitmq trigger
+const (
+	kedaDefaultMaxReplicas    = 100
+	kedaDefaultCooldownPeriod = 300
+	kedaDefaultQueueLength    = 20
+)
+
+type scaledObjectEvent struct {
+	key string
+	// obj is nil when ScaledObject is deleted
+	obj *unstructured.Unstructured
+}
+
+// importedApp is an app configuration translated from a KEDA ScaledObject
+type importedApp struct {
+	// target is the key of the scaled deployment
+	target      string
+	annotations map[string]string
+	// warnings are about parts of ScaledObject that aren't translated
+	warnings []string
+}
+
+// scaledObjectsInstalled checks that ScaledObjects are served, i.e. KEDA's CRD is installed
+func scaledObjectsInstalled(client discovery.DiscoveryInterface) (bool, error) {
+	resources, err := client.ServerResourcesForGroupVersion(scaledObjectsResource.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == scaledObjectsResource.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// watchScaledObjects sends ScaledObjects changes in the namespace to the loop
+func watchScaledObjects(ctx context.Context, client dynamic.Interface, namespace string, events chan<- scaledObjectEvent) {
+	informer := dynamicinformer.NewFilteredDynamicInformer(
+		client, scaledObjectsResource, namespace, 0, cache.Indexers{}, nil,
+	).Informer()
+
+	send := func(o interface{}) {
+		obj, ok := o.(*unstructured.Unstructured)
+		if !ok {
+			return
+		}
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err == nil {
+			events <- scaledObjectEvent{key: key, obj: obj}
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: send,
+		UpdateFunc: func(_, o interface{}) {
+			send(o)
+		},
+		DeleteFunc: func(o interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(o)
+			if err == nil {
+				events <- scaledObjectEvent{key: key}
+			}
+		},
+	})
+	go informer.Run(ctx.Done())
+}
+
+func (l *AutoscalerLoop) handleScaledObject(ctx context.Context, event scaledObjectEvent) {
+	previous, wasImported := l.imported[event.key]
+	delete(l.imported, event.key)
+
+	if event.obj != nil {
+		if enabled, _ := strconv.ParseBool(event.obj.GetAnnotations()[AnnotationPrefix+Enable]); enabled {
+			imported, err := translateScaledObject(event.obj)
+			if err != nil {
+				klog.Errorf("%s: failed to import ScaledObject: %s", event.key, err)
+				l.events.emit(event.obj, event.key, corev1.EventTypeWarning, ReasonImportError, fmt.Sprintf("Failed to import ScaledObject: %s", err))
+			} else {
+				klog.Infof("%s: importing ScaledObject for %s deployment", event.key, imported.target)
+				for _, warning := range imported.warnings {
+					klog.Warningf("%s: %s", event.key, warning)
+					l.events.emit(event.obj, event.key, corev1.EventTypeWarning, ReasonWarning, warning)
+				}
+				l.imported[event.key] = imported
+				l.refreshApp(ctx, imported.target)
+			}
+		}
+	}
+	if wasImported && previous.target != l.imported[event.key].target {
+		l.refreshApp(ctx, previous.target)
+	}
+}
+
+// importedAnnotations returns annotations translated from a ScaledObject targeting the deployment
+func (l *AutoscalerLoop) importedAnnotations(deploymentKey string) (map[string]string, bool) {
+	for _, imported := range l.imported {
+		if imported.target == deploymentKey {
+			return imported.annotations, true
+		}
+	}
+	return nil, false
+}
+
+// refreshApp fetches the deployment and updates its app, e.g. after its imported configuration is changed
+func (l *AutoscalerLoop) refreshApp(ctx context.Context, key string) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	deployment, err := l.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		l.removeApp(key)
+		return
+	}
+	if err != nil {
+		klog.Errorf("%s: failed to get deployment: %s", key, err)
+		return
+	}
+	// Errors are logged by addDeployment
+	l.addDeployment(ctx, deployment)
+}
+
+// translateScaledObject translates ScaledObject with a rabbitmq trigger to app's annotations.
+// ScaledObject's own annotations with autoscaler's prefix are added as is
+func translateScaledObject(obj *unstructured.Unstructured) (importedApp, error) {
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "kind")
+	if len(kind) > 0 && kind != "Deployment" {
+		return importedApp{}, fmt.Errorf("scale target of '%s' kind is not supported", kind)
+	}
+	target, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name")
+	if len(target) == 0 {
+		return importedApp{}, errors.New("scale target name is not specified")
+	}
+	minReplicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "minReplicaCount")
+	if err != nil {
+		return importedApp{}, err
+	}
+	maxReplicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "maxReplicaCount")
+	if err != nil {
+		return importedApp{}, err
+	}
+	if !found {
+		maxReplicas = kedaDefaultMaxReplicas
+	}
+	cooldownPeriod, found, err := unstructured.NestedInt64(obj.Object, "spec", "cooldownPeriod")
+	if err != nil {
+		return importedApp{}, err
+	}
+	if !found {
+		cooldownPeriod = kedaDefaultCooldownPeriod
+	}
+	trigger, err := rabbitMQTrigger(obj)
+	if err != nil {
+		return importedApp{}, err
+	}
+	queue := trigger["queueName"]
+	if len(queue) == 0 {
+		return importedApp{}, errors.New("trigger's queueName is not specified")
+	}
+	vhost, err := triggerVhost(trigger)
+	if err != nil {
+		return importedApp{}, err
+	}
+	var warnings []string
+	if hostEnv, ok := trigger["hostFromEnv"]; ok && len(trigger["host"]) == 0 && len(trigger["vhostName"]) == 0 {
+		// The variable can come from secrets of the target's pods, they aren't read
+		warnings = append(warnings, fmt.Sprintf(
+			"trigger's host from '%s' variable isn't resolved, '/' vhost is used, set vhostName for another vhost", hostEnv,
+		))
+	}
+	if mode, ok := trigger["mode"]; ok && mode != "QueueLength" {
+		return importedApp{}, fmt.Errorf("trigger's '%s' mode is not supported", mode)
+	}
+	messagesPerWorker, err := triggerQueueLength(trigger)
+	if err != nil {
+		return importedApp{}, err
+	}
+	// Offset is reset as KEDA requires ceil(queue length / value) replicas without extra ones
+	annotations := map[string]string{
+		AnnotationPrefix + Enable:                       "true",
+		AnnotationPrefix + "queue":                      queue,
+		AnnotationPrefix + "vhost":                      url.PathEscape(vhost),
+		AnnotationPrefix + parameters.MessagesPerWorker: strconv.Itoa(messagesPerWorker),
+		AnnotationPrefix + parameters.Offset:            "0",
+		AnnotationPrefix + parameters.Min:               strconv.FormatInt(minReplicas, 10),
+		AnnotationPrefix + parameters.Max:               strconv.FormatInt(maxReplicas, 10),
+		AnnotationPrefix + parameters.CooldownDelay:     (time.Duration(cooldownPeriod) * time.Second).String(),
+	}
+	for name, value := range obj.GetAnnotations() {
+		if strings.HasPrefix(name, AnnotationPrefix) {
+			annotations[name] = value
+		}
+	}
+	return importedApp{
+		target:      obj.GetNamespace() + "/" + target,
+		annotations: annotations,
+		warnings:    warnings,
+	}, nil
+}
+
+func rabbitMQTrigger(obj *unstructured.Unstructured) (map[string]string, error) {
+	triggers, _, err := unstructured.NestedSlice(obj.Object, "spec", "triggers")
+	if err != nil {
+		return nil, err
+	}
+	var metadata map[string]string
+	for _, t := range triggers {
+		trigger, ok := t.(map[string]interface{})
+		if !ok || trigger["type"] != "rabbitmq" {
+			continue
+		}
+		if metadata != nil {
+			return nil, errors.New("only one rabbitmq trigger is supported")
+		}
+		metadata, _, err = unstructured.NestedStringMap(trigger, "metadata")
+		if err != nil {
+			return nil, err
+		}
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+	}
+	if metadata == nil {
+		return nil, errors.New("rabbitmq trigger is not found")
+	}
+	return metadata, nil
+}
+
+// triggerVhost returns trigger's vhostName, or vhost from its host URL
+func triggerVhost(trigger map[string]string) (string, error) {
+	if vhost, ok := trigger["vhostName"]; ok {
+		return vhost, nil
+	}
+	host, ok := trigger["host"]
+	if !ok {
+		return "/", nil
+	}
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid trigger's host: %w", err)
+	}
+	if len(hostURL.Path) <= 1 {
+		return "/", nil
+	}
+	return strings.TrimPrefix(hostURL.Path, "/"), nil
+}
+
+func triggerQueueLength(trigger map[string]string) (int, error) {
+	value, ok := trigger["value"]
+	if !ok {
+		// Deprecated name of the value
+		value, ok = trigger["queueLength"]
+	}
+	if !ok {
+		return kedaDefaultQueueLength, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid trigger's value '%s': %w", value, err)
+	}
+	if f < 1 {
+		return 0, fmt.Errorf("trigger's value must be at least 1, got %s", value)
+	}
+	return int(math.Ceil(f)), nil
+}
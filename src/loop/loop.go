@@ -3,28 +3,51 @@ package loop
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/executor"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/metrics"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
+	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 type AutoscalerLoop struct {
 	add      chan *v1.Deployment
-	delete   chan *v1.Deployment
+	delete   chan string
+	metadata chan *metav1.PartialObjectMetadata
 	apps     map[string]scalable.App
 	client   *kubernetes.Clientset
 	recorder record.EventRecorder
+	events   *eventAggregator
+
+	executorCfg executor.Config
+
+	scaledObjects chan scaledObjectEvent
+	// imported contains apps configurations imported from ScaledObjects by their keys
+	imported map[string]importedApp
+
+	handoffReplicas int
+
+	// quotas contain caches of resource quotas by namespaces
+	quotas map[string]corelisters.ResourceQuotaNamespaceLister
 }
 
 type Config struct {
@@ -32,18 +55,37 @@ type Config struct {
 	InCluster       bool
 	Namespaces      string
 	LoopTickSeconds int
+	// DeploymentsSelector is a label selector of watched deployments
+	DeploymentsSelector string
+	// MetadataOnly enables caching of deployments metadata only, deployments concerned by autoscaling are fetched on changes
+	MetadataOnly bool
+	// HandoffReplicas is the replicas number set when autoscaling of the deployment is disabled,
+	// negative value keeps the current replicas number. It's overridden by the handoff-replicas annotation
+	HandoffReplicas int
+	// ImportKEDA enables import of KEDA ScaledObjects with rabbitmq triggers
+	ImportKEDA bool
 }
 
 func Launch(ctx context.Context, cfg Config) error {
 
 	l := AutoscalerLoop{
-		apps:   make(map[string]scalable.App),
-		delete: make(chan *v1.Deployment),
-		add:    make(chan *v1.Deployment),
+		apps:     make(map[string]scalable.App),
+		delete:   make(chan string),
+		add:      make(chan *v1.Deployment),
+		metadata: make(chan *metav1.PartialObjectMetadata),
+
+		executorCfg: cfg.ExecutorCfg,
+
+		scaledObjects: make(chan scaledObjectEvent),
+		imported:      map[string]importedApp{},
+
+		handoffReplicas: cfg.HandoffReplicas,
+
+		quotas: map[string]corelisters.ResourceQuotaNamespaceLister{},
 	}
 	var err error
 
-	l.client, err = discover(ctx, &l, cfg.InCluster, cfg.Namespaces)
+	l.client, err = discover(ctx, &l, cfg.InCluster, cfg.Namespaces, cfg.DeploymentsSelector, cfg.MetadataOnly, cfg.ImportKEDA)
 	if err != nil {
 		return err
 	}
@@ -52,6 +94,7 @@ func Launch(ctx context.Context, cfg Config) error {
 	if err != nil {
 		return err
 	}
+	l.events = newEventAggregator(l.recorder)
 
 	go func() {
 
@@ -60,14 +103,15 @@ func Launch(ctx context.Context, cfg Config) error {
 		for {
 			select {
 			case deployment := <-l.add:
-				if err := l.addDeployment(deployment); err != nil {
-					klog.Error(err)
+				if err := l.addDeployment(ctx, deployment); err != nil {
 					continue
 				}
-			case deployment := <-l.delete:
-				key, _ := cache.MetaNamespaceKeyFunc(deployment)
-				klog.Infof("%s: deleting app", key)
-				delete(l.apps, key)
+			case key := <-l.delete:
+				l.handleDelete(ctx, key)
+			case meta := <-l.metadata:
+				l.handleMetadata(ctx, meta)
+			case event := <-l.scaledObjects:
+				l.handleScaledObject(ctx, event)
 
 			case <-loopTick.C:
 
@@ -76,6 +120,22 @@ func Launch(ctx context.Context, cfg Config) error {
 
 				appIndex := 0
 				for _, app := range l.apps {
+					// Pods are checked only when some of them are not ready
+					if deployment, ok := app.Ref.(*v1.Deployment); ok && app.ReadyReplicas < app.Replicas {
+						statuses, err := l.podStatuses(ctx, deployment)
+						if err != nil {
+							klog.Errorf("%s: failed to get pods status: %s", app.Key, err)
+						} else {
+							app.Pods = &statuses
+						}
+					}
+					if deployment, ok := app.Ref.(*v1.Deployment); ok {
+						quotas, err := l.listResourceQuotas(app.Namespace)
+						if err != nil {
+							klog.Errorf("%s: failed to get resource quotas: %s", app.Key, err)
+						}
+						app.Quota = quotaLimit(deployment, quotas)
+					}
 					apps[appIndex] = app
 					appIndex += 1
 				}
@@ -94,10 +154,15 @@ func Launch(ctx context.Context, cfg Config) error {
 				go func() {
 					defer wg.Done()
 					for result := range results {
-						l.applyScalingResult(ctx, result, l.recorder)
+						l.applyScalingResult(ctx, result)
 					}
 				}()
 				wg.Wait()
+				if flusher, ok := cfg.ExecutorCfg.StateStore.(state.Flusher); ok {
+					if err := flusher.Flush(); err != nil {
+						klog.Errorf("Failed to persist apps state: %s", err)
+					}
+				}
 				if klog.V(2) {
 					klog.Infof("Finished scaling round in %s", time.Now().Sub(startTime))
 				}
@@ -111,15 +176,27 @@ func Launch(ctx context.Context, cfg Config) error {
 	return nil
 }
 
-func (l AutoscalerLoop) addDeployment(deployment *v1.Deployment) error {
+func (l *AutoscalerLoop) addDeployment(ctx context.Context, deployment *v1.Deployment) error {
 	key, _ := cache.MetaNamespaceKeyFunc(deployment)
 
-	app, err := createApp(deployment, key)
+	imported, _ := l.importedAnnotations(key)
+	app, err := createApp(deployment, key, imported)
 
+	if errors.Is(err, errNotConcerned) {
+		if klog.V(3) {
+			klog.Info(err)
+		}
+		l.releaseApp(ctx, key, deployment.Annotations)
+		return err
+	}
 	if err != nil {
 		klog.Error(err)
 		return err
 	}
+	if err := l.executorCfg.ValidateApp(*app); err != nil {
+		klog.Errorf("%s: invalid autoscaling configuration: %s", key, err)
+		l.events.emit(deployment, key, corev1.EventTypeWarning, ReasonInvalidConfig, fmt.Sprintf("Invalid autoscaling configuration: %s", err))
+	}
 	if _, ok := l.apps[key]; ok {
 		// Already exist
 		klog.Infof("%s: updating app", key)
@@ -131,48 +208,198 @@ func (l AutoscalerLoop) addDeployment(deployment *v1.Deployment) error {
 	return nil
 }
 
-func createApp(deployment *v1.Deployment, key string) (*scalable.App, error) {
+// releaseApp stops autoscaling of the app and sets its deployment replicas to the handoff replicas number
+func (l *AutoscalerLoop) releaseApp(ctx context.Context, key string, annotations map[string]string) {
+	app, ok := l.apps[key]
+	if !ok {
+		return
+	}
+	klog.Infof("%s: autoscaling is disabled", key)
+	l.removeApp(key)
+
+	deployment, ok := app.Ref.(*v1.Deployment)
+	if !ok {
+		return
+	}
+	replicas := l.handoffReplicas
+	if value, ok := annotations[AnnotationPrefix+HandoffReplicas]; ok {
+		var err error
+		if replicas, err = strconv.Atoi(value); err != nil {
+			klog.Errorf("%s: invalid %s annotation value: %s", key, HandoffReplicas, err)
+			return
+		}
+	}
+	if replicas < 0 {
+		return
+	}
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	_, err := l.client.AppsV1().Deployments(deployment.Namespace).Patch(ctx, deployment.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("%s: failed to set handoff replicas: %s", key, err)
+		return
+	}
+	klog.Infof("%s: replicas are set to %d on handoff", key, replicas)
+	l.events.emitAlways(deployment, key, corev1.EventTypeNormal, ReasonHandoff, fmt.Sprintf("Autoscaling is disabled, replicas are set to %d", replicas))
+}
+
+// handleDelete removes the app of the deployment deleted from the cache. The deployment that still exists
+// doesn't match the deployments selector anymore, so its app is released
+func (l *AutoscalerLoop) handleDelete(ctx context.Context, key string) {
+	app, ok := l.apps[key]
+	if !ok {
+		return
+	}
+	deployment, err := l.client.AppsV1().Deployments(app.Namespace).Get(ctx, app.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("%s: failed to get deleted deployment: %s", key, err)
+		}
+		l.removeApp(key)
+		return
+	}
+	l.releaseApp(ctx, key, deployment.Annotations)
+}
+
+func (l AutoscalerLoop) removeApp(key string) {
+	if _, ok := l.apps[key]; !ok {
+		return
+	}
+	klog.Infof("%s: deleting app", key)
+	delete(l.apps, key)
+	if l.executorCfg.StateStore != nil {
+		l.executorCfg.StateStore.Delete(key)
+	}
+	metrics.ForgetApp(key)
+	l.events.forget(key)
+}
+
+// handleMetadata fetches the deployment if it's concerned by autoscaling, otherwise its app is released.
+// Status-only updates of a settled deployment don't change its app, e.g. the ones caused by its scaling, so it isn't fetched
+func (l *AutoscalerLoop) handleMetadata(ctx context.Context, meta *metav1.PartialObjectMetadata) {
+	key, _ := cache.MetaNamespaceKeyFunc(meta)
+
+	disabled := false
+	if value, ok := meta.Annotations[AnnotationPrefix+Enable]; ok {
+		// Invalid values are reported when the deployment is fetched
+		enabled, err := strconv.ParseBool(value)
+		disabled = err == nil && !enabled
+	} else {
+		_, imported := l.importedAnnotations(key)
+		disabled = !imported
+	}
+	if disabled {
+		l.releaseApp(ctx, key, meta.Annotations)
+		return
+	}
+	if app, ok := l.apps[key]; ok && !metadataChanged(app, meta) {
+		return
+	}
+	l.refreshApp(ctx, key)
+}
 
-	if _, ok := deployment.ObjectMeta.Annotations[AnnotationPrefix+Enable]; !ok {
-		return nil, errors.New(key + " not concerned by autoscaling, skipping")
+// metadataChanged checks whether the app's deployment has to be fetched after its metadata update.
+// The deployment is fetched while its rollout isn't finished or its pods aren't ready to keep its status up to date
+func metadataChanged(app scalable.App, meta *metav1.PartialObjectMetadata) bool {
+	deployment, ok := app.Ref.(*v1.Deployment)
+	if !ok || deployment.Generation != meta.Generation || !reflect.DeepEqual(deployment.Annotations, meta.Annotations) {
+		return true
+	}
+	return app.ReadyReplicas < app.Replicas || app.RollingOut()
+}
+
+var errNotConcerned = errors.New("not concerned by autoscaling, skipping")
+
+// createApp creates app for the deployment, imported annotations are used if the deployment doesn't have enable annotation.
+// Autoscaling disabled by the annotation isn't enabled by imported ones
+func createApp(deployment *v1.Deployment, key string, imported map[string]string) (*scalable.App, error) {
+	annotations := deployment.Annotations
+
+	if value, ok := deployment.Annotations[AnnotationPrefix+Enable]; ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid %s annotation value: %w", key, Enable, err)
+		}
+		if !enabled {
+			return nil, fmt.Errorf("%s %w", key, errNotConcerned)
+		}
+	} else {
+		if imported == nil {
+			return nil, fmt.Errorf("%s %w", key, errNotConcerned)
+		}
+		annotations = map[string]string{}
+		for name, value := range deployment.Annotations {
+			annotations[name] = value
+		}
+		for name, value := range imported {
+			annotations[name] = value
+		}
 	}
 
 	return &scalable.App{
 		Ref:           deployment,
 		Key:           key,
+		Namespace:     deployment.Namespace,
 		Name:          deployment.Name,
 		Replicas:      int(*deployment.Spec.Replicas),
 		ReadyReplicas: int(deployment.Status.ReadyReplicas),
 		UpdatedDate:   time.Now(),
-		Annotations:   &deployment.Annotations,
+		Annotations:   &annotations,
+		Rollout: scalable.RolloutStatus{
+			Generation:          deployment.Generation,
+			ObservedGeneration:  deployment.Status.ObservedGeneration,
+			UpdatedReplicas:     int(deployment.Status.UpdatedReplicas),
+			UnavailableReplicas: int(deployment.Status.UnavailableReplicas),
+		},
 	}, nil
 }
 
 func (l *AutoscalerLoop) handleError(err executor.Error) {
 	klog.Errorf("Got error during strategies execution: %s", err)
-	baseErr, ok := err.(executor.BaseError)
-	if !ok {
+	var baseErr executor.BaseError
+	message := ""
+	switch e := err.(type) {
+	case executor.BaseError:
+		baseErr = e
+		message = fmt.Sprintf("Scaling failed: %s", e.Err)
+	case executor.ProviderError:
+		baseErr = e.BaseError
+		message = fmt.Sprintf("Scaling failed: '%s' provider error: %s", e.ProviderName, e.Err)
+	default:
 		return
 	}
 	depl, ok := baseErr.App.Ref.(*v1.Deployment)
-	l.recorder.Eventf(depl, corev1.EventTypeWarning, "ASWarning", "error during scaling", err)
+	if !ok {
+		return
+	}
+	l.events.emit(depl, baseErr.App.Key, corev1.EventTypeWarning, ReasonScalingError, message)
 }
 
-func (l *AutoscalerLoop) applyScalingResult(ctx context.Context, result strategy.Result, recorder record.EventRecorder) {
+func (l *AutoscalerLoop) applyScalingResult(ctx context.Context, result strategy.Result) {
 	app := result.App
 
 	ref, ok := app.Ref.(*v1.Deployment)
 	if !ok {
-		klog.Errorf("%s app ref contains value of unexpected type: expected *v1.Deployment, got %t", app.Key, app.Ref)
+		klog.Errorf("%s app ref contains value of unexpected type: expected *v1.Deployment, got %T", app.Key, app.Ref)
 		return
 	}
+	for _, warning := range result.Warnings {
+		klog.Warningf("%s: %s", app.Key, warning)
+	}
+	if len(result.Warnings) > 0 {
+		l.events.emit(ref, app.Key, corev1.EventTypeWarning, ReasonWarning, strings.Join(result.Warnings, "; "))
+	}
 	if result.Skip {
-		klog.Infof("%s scaling will be skipped", app.Key)
+		if len(result.SkipReason) == 0 {
+			klog.Infof("%s scaling will be skipped", app.Key)
+			return
+		}
+		klog.Infof("%s scaling will be skipped: %s", app.Key, result.SkipReason)
+		l.events.emit(ref, app.Key, corev1.EventTypeNormal, ReasonSkip, fmt.Sprintf("Scaling will be skipped: %s", result.SkipReason))
 		return
 	}
 	if int(*ref.Spec.Replicas) == result.RequiredReplicas {
 		klog.Infof("%s scaling will be skipped: requested replicas number hasn't changed", app.Key)
-		recorder.Eventf(ref, corev1.EventTypeNormal, "ASSkip", "Scaling will be skipped: requested replicas number hasn't changed")
+		l.events.emit(ref, app.Key, corev1.EventTypeNormal, ReasonSkip, "Scaling will be skipped: requested replicas number hasn't changed")
 		return
 	}
 	klog.Infof("%s Will be updated from %d replicas to %d", app.Key, app.Replicas, result.RequiredReplicas)
@@ -181,9 +408,14 @@ func (l *AutoscalerLoop) applyScalingResult(ctx context.Context, result strategy
 	increment := result.RequiredReplicas - app.Replicas
 
 	if increment > 0 {
-		recorder.Eventf(ref, corev1.EventTypeNormal, "ASScaleUp", "Scaling up to %d replicas", newReplicas)
+		l.events.emitAlways(ref, app.Key, corev1.EventTypeNormal, ReasonScaleUp, fmt.Sprintf("Scaling up from %d to %d replicas", app.Replicas, newReplicas))
 	} else if increment < 0 {
-		recorder.Eventf(ref, corev1.EventTypeNormal, "ASScaleDown", "Scaling down to %d replicas", newReplicas)
+		l.events.emitAlways(ref, app.Key, corev1.EventTypeNormal, ReasonScaleDown, fmt.Sprintf("Scaling down from %d to %d replicas", app.Replicas, newReplicas))
+		if result.PodDeletionCosts != nil {
+			if err := l.setPodDeletionCosts(ctx, ref, result.PodDeletionCosts); err != nil {
+				klog.Errorf("%s: failed to set pod deletion costs: %s", app.Key, err)
+			}
+		}
 	}
 
 	ref.Spec.Replicas = &newReplicas
@@ -191,8 +423,12 @@ func (l *AutoscalerLoop) applyScalingResult(ctx context.Context, result strategy
 
 	if err != nil {
 		klog.Errorf("Error during deployment (%s) update, retry later (%s)", app.Key, err)
-	} else {
-		app.Ref = newRef
+		return
+	}
+	app.Ref = newRef
+
+	if err := modifiers.RecordScaleEvent(app, increment); err != nil {
+		klog.Errorf("%s: %s", app.Key, err)
 	}
 }
 
@@ -0,0 +1,71 @@
+package loop
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestMetadataChanged(t *testing.T) {
+	annotations := map[string]string{AnnotationPrefix + Enable: "true"}
+	deployment := &v1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 2, Annotations: annotations}}
+	settled := scalable.App{
+		Ref:           deployment,
+		Replicas:      3,
+		ReadyReplicas: 3,
+		Rollout:       scalable.RolloutStatus{Generation: 2, ObservedGeneration: 2, UpdatedReplicas: 3},
+	}
+	notReady := settled
+	notReady.ReadyReplicas = 2
+	rollingOut := settled
+	rollingOut.Rollout.ObservedGeneration = 1
+
+	testCases := []struct {
+		name     string
+		app      scalable.App
+		meta     metav1.ObjectMeta
+		expected bool
+	}{
+		{
+			name:     "status only update",
+			app:      settled,
+			meta:     metav1.ObjectMeta{Generation: 2, Annotations: annotations},
+			expected: false,
+		},
+		{
+			name:     "generation changed",
+			app:      settled,
+			meta:     metav1.ObjectMeta{Generation: 3, Annotations: annotations},
+			expected: true,
+		},
+		{
+			name: "annotations changed",
+			app:  settled,
+			meta: metav1.ObjectMeta{
+				Generation:  2,
+				Annotations: map[string]string{AnnotationPrefix + Enable: "true", AnnotationPrefix + "queue": "jobs"},
+			},
+			expected: true,
+		},
+		{
+			name:     "pods aren't ready",
+			app:      notReady,
+			meta:     metav1.ObjectMeta{Generation: 2, Annotations: annotations},
+			expected: true,
+		},
+		{
+			name:     "rolling out",
+			app:      rollingOut,
+			meta:     metav1.ObjectMeta{Generation: 2, Annotations: annotations},
+			expected: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			meta := &metav1.PartialObjectMetadata{ObjectMeta: testCase.meta}
+			require.Equal(t, testCase.expected, metadataChanged(testCase.app, meta))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package loop
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const PodDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+// setPodDeletionCosts annotates deployment's pods with deletion costs by their IPs,
+// pods with unknown IPs get zero cost
+func (l *AutoscalerLoop) setPodDeletionCosts(ctx context.Context, deployment *v1.Deployment, costs map[string]int) error {
+	pods, err := l.listPods(ctx, deployment)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || len(pod.Status.PodIP) == 0 {
+			continue
+		}
+		cost := strconv.Itoa(costs[pod.Status.PodIP])
+		if pod.Annotations[PodDeletionCostAnnotation] == cost {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{PodDeletionCostAnnotation: cost},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = l.client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listPods returns deployment's pods. Pods are listed only when they are needed, i.e. when some of them are not ready
+// or before scaling down, so they aren't cached. They are served from API server's cache
+func (l *AutoscalerLoop) listPods(ctx context.Context, deployment *v1.Deployment) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := l.client.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector:   selector.String(),
+		ResourceVersion: "0",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// podStatuses returns statuses of deployment's pods that are not being deleted
+func (l *AutoscalerLoop) podStatuses(ctx context.Context, deployment *v1.Deployment) ([]scalable.PodStatus, error) {
+	pods, err := l.listPods(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []scalable.PodStatus
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		status := scalable.PodStatus{
+			Name:          pod.Name,
+			Ready:         podReady(pod),
+			Unschedulable: podUnschedulable(pod),
+		}
+		for _, containers := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+			for _, container := range containers {
+				status.Restarts += int(container.RestartCount)
+				if container.State.Waiting != nil && container.State.Waiting.Reason == "CrashLoopBackOff" {
+					status.CrashLooping = true
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func podUnschedulable(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
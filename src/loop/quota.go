@@ -0,0 +1,157 @@
+package loop
+
+import (
+	"fmt"
+
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func (l *AutoscalerLoop) listResourceQuotas(namespace string) ([]*corev1.ResourceQuota, error) {
+	quotas, ok := l.quotas[namespace]
+	if !ok {
+		return nil, fmt.Errorf("'%s' namespace is not watched", namespace)
+	}
+	return quotas.List(labels.Everything())
+}
+
+// quotaLimit computes the maximum number of deployment's replicas that fit into the resource quotas.
+// Quotas which scopes don't match deployment's pods are ignored
+func quotaLimit(deployment *v1.Deployment, quotas []*corev1.ResourceQuota) *scalable.QuotaLimit {
+	var limit *scalable.QuotaLimit
+	for _, quota := range quotas {
+		if !quotaMatches(quota.Spec, deployment.Spec.Template.Spec) {
+			continue
+		}
+		for name, hard := range quota.Status.Hard {
+			perPod, ok := podUsage(deployment.Spec.Template.Spec, name)
+			if !ok || perPod <= 0 {
+				continue
+			}
+			used := quota.Status.Used[name]
+			available := hard.MilliValue() - used.MilliValue()
+			if available < 0 {
+				available = 0
+			}
+			// Existing pods are already counted as used
+			maxReplicas := int(deployment.Status.Replicas) + int(available/perPod)
+			if limit == nil || maxReplicas < limit.MaxReplicas {
+				limit = &scalable.QuotaLimit{
+					MaxReplicas: maxReplicas,
+					Quota:       quota.Name,
+					Resource:    string(name),
+				}
+			}
+		}
+	}
+	return limit
+}
+
+// quotaMatches reports whether quota's scopes and scope selector match pods with the given spec.
+// Quotas with scopes that can't be evaluated by the spec are not matched
+func quotaMatches(quota corev1.ResourceQuotaSpec, spec corev1.PodSpec) bool {
+	var requirements []corev1.ScopedResourceSelectorRequirement
+	for _, scope := range quota.Scopes {
+		requirements = append(requirements, corev1.ScopedResourceSelectorRequirement{
+			ScopeName: scope,
+			Operator:  corev1.ScopeSelectorOpExists,
+		})
+	}
+	if quota.ScopeSelector != nil {
+		requirements = append(requirements, quota.ScopeSelector.MatchExpressions...)
+	}
+	for _, requirement := range requirements {
+		if !scopeMatches(requirement, spec) {
+			return false
+		}
+	}
+	return true
+}
+
+func scopeMatches(requirement corev1.ScopedResourceSelectorRequirement, spec corev1.PodSpec) bool {
+	switch requirement.ScopeName {
+	case corev1.ResourceQuotaScopeTerminating:
+		return spec.ActiveDeadlineSeconds != nil && *spec.ActiveDeadlineSeconds >= 0
+	case corev1.ResourceQuotaScopeNotTerminating:
+		return spec.ActiveDeadlineSeconds == nil || *spec.ActiveDeadlineSeconds < 0
+	case corev1.ResourceQuotaScopeBestEffort:
+		return bestEffort(spec)
+	case corev1.ResourceQuotaScopeNotBestEffort:
+		return !bestEffort(spec)
+	case corev1.ResourceQuotaScopePriorityClass:
+		listed := false
+		for _, value := range requirement.Values {
+			listed = listed || value == spec.PriorityClassName
+		}
+		switch requirement.Operator {
+		case corev1.ScopeSelectorOpExists:
+			return len(spec.PriorityClassName) > 0
+		case corev1.ScopeSelectorOpDoesNotExist:
+			return len(spec.PriorityClassName) == 0
+		case corev1.ScopeSelectorOpIn:
+			return listed
+		case corev1.ScopeSelectorOpNotIn:
+			return !listed
+		}
+	}
+	return false
+}
+
+// bestEffort reports whether pods with the given spec have BestEffort QoS class
+func bestEffort(spec corev1.PodSpec) bool {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			for _, resources := range []corev1.ResourceList{container.Resources.Requests, container.Resources.Limits} {
+				for name := range resources {
+					if name == corev1.ResourceCPU || name == corev1.ResourceMemory {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// podUsage returns amount of the quota resource in milli-units used by a pod with the given spec
+func podUsage(spec corev1.PodSpec, name corev1.ResourceName) (int64, bool) {
+	switch name {
+	case corev1.ResourcePods:
+		return 1000, true
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return containersResource(spec, name, false), true
+	case corev1.ResourceRequestsCPU, corev1.ResourceRequestsMemory, corev1.ResourceRequestsEphemeralStorage:
+		return containersResource(spec, corev1.ResourceName(string(name)[len("requests."):]), false), true
+	case corev1.ResourceLimitsCPU, corev1.ResourceLimitsMemory, corev1.ResourceLimitsEphemeralStorage:
+		return containersResource(spec, corev1.ResourceName(string(name)[len("limits."):]), true), true
+	}
+	return 0, false
+}
+
+// containersResource returns pod's effective request or limit in milli-units:
+// the largest of the sum of containers' values and the init containers' values
+func containersResource(spec corev1.PodSpec, name corev1.ResourceName, limits bool) int64 {
+	value := func(container corev1.Container) resource.Quantity {
+		if limits {
+			return container.Resources.Limits[name]
+		}
+		return container.Resources.Requests[name]
+	}
+	var sum int64
+	for _, container := range spec.Containers {
+		q := value(container)
+		sum += q.MilliValue()
+	}
+	for _, container := range spec.InitContainers {
+		if q := value(container); q.MilliValue() > sum {
+			sum = q.MilliValue()
+		}
+	}
+	if q, ok := spec.Overhead[name]; ok {
+		sum += q.MilliValue()
+	}
+	return sum
+}
@@ -0,0 +1,100 @@
+package loop
+
+import (
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"testing"
+)
+
+func TestQuotaMatches(t *testing.T) {
+	deadline := int64(60)
+	burstable := corev1.PodSpec{
+		PriorityClassName: "high",
+		Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			},
+		}},
+	}
+	bestEffort := corev1.PodSpec{Containers: []corev1.Container{{}}, ActiveDeadlineSeconds: &deadline}
+
+	testCases := []struct {
+		name     string
+		quota    corev1.ResourceQuotaSpec
+		spec     corev1.PodSpec
+		expected bool
+	}{
+		{name: "no scopes", spec: burstable, expected: true},
+		{
+			name:     "not best effort",
+			quota:    corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotBestEffort}},
+			spec:     burstable,
+			expected: true,
+		},
+		{
+			name:     "best effort",
+			quota:    corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}},
+			spec:     burstable,
+			expected: false,
+		},
+		{
+			name: "terminating",
+			quota: corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{
+				corev1.ResourceQuotaScopeTerminating, corev1.ResourceQuotaScopeBestEffort,
+			}},
+			spec:     bestEffort,
+			expected: true,
+		},
+		{
+			name:     "not terminating",
+			quota:    corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotTerminating}},
+			spec:     bestEffort,
+			expected: false,
+		},
+		{
+			name: "priority class in",
+			quota: corev1.ResourceQuotaSpec{ScopeSelector: &corev1.ScopeSelector{
+				MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+					ScopeName: corev1.ResourceQuotaScopePriorityClass,
+					Operator:  corev1.ScopeSelectorOpIn,
+					Values:    []string{"low", "high"},
+				}},
+			}},
+			spec:     burstable,
+			expected: true,
+		},
+		{
+			name: "priority class not in",
+			quota: corev1.ResourceQuotaSpec{ScopeSelector: &corev1.ScopeSelector{
+				MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+					ScopeName: corev1.ResourceQuotaScopePriorityClass,
+					Operator:  corev1.ScopeSelectorOpNotIn,
+					Values:    []string{"high"},
+				}},
+			}},
+			spec:     burstable,
+			expected: false,
+		},
+		{
+			name: "priority class doesn't exist",
+			quota: corev1.ResourceQuotaSpec{ScopeSelector: &corev1.ScopeSelector{
+				MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+					ScopeName: corev1.ResourceQuotaScopePriorityClass,
+					Operator:  corev1.ScopeSelectorOpDoesNotExist,
+				}},
+			}},
+			spec:     bestEffort,
+			expected: true,
+		},
+		{
+			name:     "unknown scope",
+			quota:    corev1.ResourceQuotaSpec{Scopes: []corev1.ResourceQuotaScope{"CrossNamespacePodAffinity"}},
+			spec:     burstable,
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, quotaMatches(tc.quota, tc.spec), tc.name)
+	}
+}
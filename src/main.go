@@ -3,19 +3,31 @@ package main
 import (
 	"context"
 	"flag"
+	"github.com/go-redis/redis/v8"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/executor"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/externalmetrics"
 	"github.com/medal-labs/k8s-rmq-autoscaler/loop"
+	"github.com/medal-labs/k8s-rmq-autoscaler/metrics"
 	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
 	"github.com/medal-labs/k8s-rmq-autoscaler/providers"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/kafkalag"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/promql"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/redislen"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/rmqamqp"
 	"github.com/medal-labs/k8s-rmq-autoscaler/providers/rmqhttp"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/rmqprometheus"
 	"github.com/medal-labs/k8s-rmq-autoscaler/strategies"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
 	"k8s.io/klog"
 	"os"
 	"regexp"
+	"time"
 )
 
 type EnvConfig struct {
@@ -24,9 +36,41 @@ type EnvConfig struct {
 	RMQUrl          string `envconfig:"RMQ_URL" required:"true"`
 	RMQUser         string `envconfig:"RMQ_USER" required:"true"`
 	RMQPassword     string `envconfig:"RMQ_PASSWORD" required:"true"`
+	AMQPUrl         string `envconfig:"AMQP_URL" default:""`
 	Tick            int    `envconfig:"TICK" default:"10"`
 	LogLevel        string `envconfig:"MDL_COMN_LOGLEVEL" default:"INFO"`
 	DefaultStrategy string `envconfig:"K8S_AUTOSCALER_DEFAULT_STRATEGY" default:"simple-queue-based"`
+	StateFile       string `envconfig:"STATE_FILE" default:""`
+	MetricsAddress  string `envconfig:"METRICS_ADDRESS" default:":9102"`
+	ImportKEDA      bool   `envconfig:"IMPORT_KEDA" default:"false"`
+	Selector        string `envconfig:"DEPLOYMENTS_SELECTOR" default:""`
+	MetadataOnly    bool   `envconfig:"WATCH_METADATA_ONLY" default:"false"`
+	HandoffReplicas int    `envconfig:"HANDOFF_REPLICAS" default:"-1"`
+	ClusterBudget   int    `envconfig:"CLUSTER_BUDGET" default:"0"`
+	NamespaceBudget int    `envconfig:"NAMESPACE_BUDGET" default:"0"`
+
+	RMQPrometheusUrl     string        `envconfig:"RMQ_PROMETHEUS_URL" default:""`
+	RMQPrometheusTimeout time.Duration `envconfig:"RMQ_PROMETHEUS_TIMEOUT" default:"10s"`
+
+	AMQPTimeout time.Duration `envconfig:"AMQP_TIMEOUT" default:"10s"`
+
+	PrometheusUrl    string        `envconfig:"PROMETHEUS_URL" default:""`
+	PromQLParameters string        `envconfig:"PROMQL_PARAMETERS" default:""`
+	PromQLTimeout    time.Duration `envconfig:"PROMQL_TIMEOUT" default:"10s"`
+	PromQLStaleness  time.Duration `envconfig:"PROMQL_STALENESS" default:"0s"`
+
+	KafkaBrokers []string      `envconfig:"KAFKA_BROKERS" default:""`
+	KafkaTimeout time.Duration `envconfig:"KAFKA_TIMEOUT" default:"10s"`
+
+	RedisUrl              string        `envconfig:"REDIS_URL" default:""`
+	RedisTimeout          time.Duration `envconfig:"REDIS_TIMEOUT" default:"10s"`
+	RedisUndeliveredLimit int64         `envconfig:"REDIS_UNDELIVERED_LIMIT" default:"1000"`
+
+	ExternalMetricsAddress  string        `envconfig:"EXTERNAL_METRICS_ADDRESS" default:""`
+	ExternalMetricsCertFile string        `envconfig:"EXTERNAL_METRICS_CERT_FILE" default:""`
+	ExternalMetricsKeyFile  string        `envconfig:"EXTERNAL_METRICS_KEY_FILE" default:""`
+	ExternalMetricsCacheTTL time.Duration `envconfig:"EXTERNAL_METRICS_CACHE_TTL" default:"10s"`
+	ExternalMetricsTimeout  time.Duration `envconfig:"EXTERNAL_METRICS_TIMEOUT" default:"10s"`
 }
 
 func main() {
@@ -41,27 +85,119 @@ func main() {
 	configureLogLevel(cfg)
 	flag.Parse()
 
+	rmqHTTPConfig := rmqhttp.Config{
+		Name:     "rmq-http-provider",
+		Url:      cfg.RMQUrl,
+		User:     cfg.RMQUser,
+		Password: cfg.RMQPassword,
+	}
+	promQLParameters, err := promql.ParseParameters(cfg.PromQLParameters)
+	if err != nil {
+		klog.Error(err)
+		os.Exit(1)
+	}
+	var redisOptions *redis.Options
+	if len(cfg.RedisUrl) > 0 {
+		if redisOptions, err = redis.ParseURL(cfg.RedisUrl); err != nil {
+			klog.Error(err)
+			os.Exit(1)
+		}
+	}
 	enabledProviders := providers.Configure(
 		providers.Config{
-			RMQHTTP: rmqhttp.Config{
-				Name:     "rmq-http-provider",
-				Url:      cfg.RMQUrl,
+			RMQHTTP: rmqHTTPConfig,
+			RMQAMQP: rmqamqp.Config{
+				Name:     "rmq-amqp-provider",
+				Url:      cfg.AMQPUrl,
 				User:     cfg.RMQUser,
 				Password: cfg.RMQPassword,
+				Timeout:  cfg.AMQPTimeout,
+			},
+			RMQPrometheus: rmqprometheus.Config{
+				Name:    "rmq-prometheus-provider",
+				Url:     cfg.RMQPrometheusUrl,
+				Timeout: cfg.RMQPrometheusTimeout,
+			},
+			PromQL: promql.Config{
+				Name:       "promql-provider",
+				Url:        cfg.PrometheusUrl,
+				Parameters: promQLParameters,
+				Timeout:    cfg.PromQLTimeout,
+				Staleness:  cfg.PromQLStaleness,
+			},
+			KafkaLag: kafkalag.Config{
+				Name:    "kafka-lag-provider",
+				Brokers: cfg.KafkaBrokers,
+				Timeout: cfg.KafkaTimeout,
+			},
+			RedisLen: redislen.Config{
+				Name:             "redis-length-provider",
+				Options:          redisOptions,
+				Timeout:          cfg.RedisTimeout,
+				UndeliveredLimit: cfg.RedisUndeliveredLimit,
 			},
 		},
 	)
+	stateStore, err := configureStateStore(cfg)
+	if err != nil {
+		klog.Error(err)
+		os.Exit(1)
+	}
 	executorCfg := executor.Config{
 		EnabledStrategies: []strategy.Config{
 			strategies.SimpleQueueBased,
+			strategies.OldestMessageAge,
+			strategies.QueueLengthPID,
+			strategies.MessageAgePID,
+			strategies.PredictiveQueueBased,
+			strategies.Composite,
+			strategies.Expression,
+		},
+		EnabledModifiers: []strategy.ResultModifier{
+			modifiers.ScalingMode,
+			modifiers.Stabilization,
+			modifiers.Tolerance,
+			modifiers.WithSteps,
+			modifiers.MinMax,
+			modifiers.ResourceQuota,
+			modifiers.SkipUnstable,
+			modifiers.OverrideLimits,
+			modifiers.SafeUnscale,
+			modifiers.Cooldown,
+			modifiers.Behavior,
+			modifiers.PartitionCap,
 		},
 		EnabledProviders:  enabledProviders,
 		AnnotationsPrefix: "k8s-rmq-autoscaler/",
 		DefaultStrategy:   strategy.YAMLName(cfg.DefaultStrategy),
 		DefaultParametersProviders: map[parameter.Name]provider.Name{
-			parameters.QueueLength: "rmq-http-provider",
+			parameters.QueueLength:            "rmq-http-provider",
+			parameters.HeadMessageAge:         "rmq-http-provider",
+			parameters.PublishRate:            "rmq-http-provider",
+			parameters.DeliverRate:            "rmq-http-provider",
+			parameters.MessagesUnacknowledged: "rmq-http-provider",
+			parameters.ConsumersInFlight:      "rmq-http-provider",
+		},
+		StateStore: stateStore,
+		Budget: executor.BudgetConfig{
+			Cluster:   cfg.ClusterBudget,
+			Namespace: cfg.NamespaceBudget,
 		},
 	}
+	if len(cfg.KafkaBrokers) > 0 {
+		// Partitions are provided only for apps reading queue length from Kafka, see selection of optional parameters
+		executorCfg.DefaultParametersProviders[parameters.Partitions] = "kafka-lag-provider"
+	}
+	if len(cfg.PrometheusUrl) > 0 {
+		builtIn := builtInParameters(executorCfg, "promql-provider")
+		for name := range promQLParameters {
+			if builtIn[name] {
+				klog.Infof("'%s' is a built-in parameter, it's provided by promql-provider only for apps selecting it in annotations", name)
+				continue
+			}
+			executorCfg.DefaultParametersProviders[name] = "promql-provider"
+		}
+	}
 	errs := executorCfg.Validate()
 	if len(errs) > 0 {
 		for _, err := range errs {
@@ -70,13 +206,42 @@ func main() {
 		}
 	}
 
+	if len(cfg.MetricsAddress) > 0 {
+		metrics.Serve(ctx, cfg.MetricsAddress)
+	}
+	if len(cfg.ExternalMetricsAddress) > 0 {
+		client, err := loop.NewClient(cfg.InCluster)
+		if err != nil {
+			klog.Error(err)
+			os.Exit(1)
+		}
+		err = externalmetrics.Serve(ctx, externalmetrics.Config{
+			Address:           cfg.ExternalMetricsAddress,
+			CertFile:          cfg.ExternalMetricsCertFile,
+			KeyFile:           cfg.ExternalMetricsKeyFile,
+			Client:            client,
+			Provider:          rmqhttp.ProviderConfig(rmqHTTPConfig),
+			AnnotationsPrefix: common.AnnotationPrefix,
+			CacheTTL:          cfg.ExternalMetricsCacheTTL,
+			Timeout:           cfg.ExternalMetricsTimeout,
+		})
+		if err != nil {
+			klog.Error(err)
+			os.Exit(1)
+		}
+	}
 	loopCfg := loop.Config{
 		ExecutorCfg:     executorCfg,
 		InCluster:       cfg.InCluster,
 		Namespaces:      cfg.Namespaces,
 		LoopTickSeconds: cfg.Tick,
+		ImportKEDA:      cfg.ImportKEDA,
+
+		DeploymentsSelector: cfg.Selector,
+		MetadataOnly:        cfg.MetadataOnly,
+		HandoffReplicas:     cfg.HandoffReplicas,
 	}
-	err := loop.Launch(ctx, loopCfg)
+	err = loop.Launch(ctx, loopCfg)
 	if err != nil {
 		klog.Error(err)
 		os.Exit(128)
@@ -84,6 +249,40 @@ func main() {
 	<-ctx.Done()
 }
 
+// builtInParameters returns names of parameters provided by other providers or required by strategies and modifiers
+func builtInParameters(cfg executor.Config, except provider.Name) map[parameter.Name]bool {
+	names := map[parameter.Name]bool{}
+	for name := range cfg.DefaultParametersProviders {
+		names[name] = true
+	}
+	for _, providerCfg := range cfg.EnabledProviders {
+		if providerCfg.Name == except {
+			continue
+		}
+		for name := range providerCfg.AvailableParameters {
+			names[name] = true
+		}
+	}
+	for _, strategyCfg := range cfg.EnabledStrategies {
+		for name := range strategyCfg.GetRequiredParameters() {
+			names[name] = true
+		}
+	}
+	for _, modifier := range cfg.EnabledModifiers {
+		for name := range modifier.RequiredParameters {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func configureStateStore(cfg EnvConfig) (state.Store, error) {
+	if len(cfg.StateFile) == 0 {
+		return state.NewMemoryStore(), nil
+	}
+	return state.NewFileStore(cfg.StateFile)
+}
+
 func configureLogLevel(cfg EnvConfig) {
 	klog.InitFlags(nil)
 
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog"
+	"net/http"
+	"time"
+)
+
+const namespace = "k8s_rmq_autoscaler"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	ForecastQueueLength = newAppGauge(
+		"forecast_queue_length",
+		"Queue length predicted by the predictive strategy for the end of the forecast lead time",
+	)
+	ForecastPublishRate = newAppGauge(
+		"forecast_publish_rate",
+		"Publish rate predicted by the predictive strategy for the end of the forecast lead time",
+	)
+	ForecastRequiredReplicas = newAppGauge(
+		"forecast_required_replicas",
+		"Replicas number required to handle the predicted load",
+	)
+)
+
+var appVecs []*prometheus.GaugeVec
+
+func newAppGauge(name string, help string) *prometheus.GaugeVec {
+	vec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		},
+		[]string{"app"},
+	)
+	registry.MustRegister(vec)
+	appVecs = append(appVecs, vec)
+	return vec
+}
+
+// ForgetApp removes metrics of the app that is no longer tracked
+func ForgetApp(appKey string) {
+	for _, vec := range appVecs {
+		vec.DeleteLabelValues(appKey)
+	}
+}
+
+// Serve exposes metrics in Prometheus format on the '/metrics' path until the context is done
+func Serve(ctx context.Context, address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: address, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		klog.Infof("Serving metrics on %s", address)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("Metrics server failed: %s", err)
+		}
+	}()
+}
@@ -13,3 +13,62 @@ const (
 	Override                         = "override"
 	SafeUnscale                      = "safe-unscale"
 )
+
+const (
+	HeadMessageAge               parameter.Name = "head-message-age"
+	TargetMessageAge                            = "target-message-age"
+	MessageAgeScaleUpThreshold                  = "message-age-scale-up-threshold"
+	MessageAgeScaleDownThreshold                = "message-age-scale-down-threshold"
+)
+
+const (
+	PIDSetpoint parameter.Name = "pid-setpoint"
+	PIDKp                      = "pid-kp"
+	PIDKi                      = "pid-ki"
+	PIDKd                      = "pid-kd"
+)
+
+const (
+	PublishRate       parameter.Name = "publish-rate"
+	DeliverRate                      = "deliver-rate"
+	ForecastLead                     = "forecast-lead"
+	ForecastSeason                   = "forecast-season"
+	ForecastBucket                   = "forecast-bucket"
+	ForecastSmoothing                = "forecast-smoothing"
+)
+
+const (
+	ScaleDownStabilizationWindow parameter.Name = "scale-down-stabilization-window"
+	ScaleUpStabilizationWindow                  = "scale-up-stabilization-window"
+	ScalingBehavior                             = "scaling-behavior"
+	ScalingMode                                 = "scaling-mode"
+)
+
+const (
+	ScaleUpTolerance           parameter.Name = "scale-up-tolerance"
+	ScaleDownTolerance                        = "scale-down-tolerance"
+	ScaleUpAbsoluteTolerance                  = "scale-up-absolute-tolerance"
+	ScaleDownAbsoluteTolerance                = "scale-down-absolute-tolerance"
+)
+
+const (
+	MessagesUnacknowledged parameter.Name = "messages-unacknowledged"
+	ConsumersInFlight                     = "consumers-in-flight"
+	SafeUnscaleMaxInFlight                = "safe-unscale-max-in-flight"
+	Consumers                             = "consumers"
+)
+
+const (
+	MessagesReady parameter.Name = "messages-ready"
+)
+
+const (
+	Partitions   parameter.Name = "partitions"
+	PartitionLag                = "partition-lag"
+)
+
+const (
+	UnstableTolerance    parameter.Name = "unstable-tolerance"
+	ScaleUpDuringRollout                = "scale-up-during-rollout"
+	CrashLoopRestarts                   = "crash-loop-restarts"
+)
@@ -2,15 +2,46 @@ package providers
 
 import (
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/kafkalag"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/promql"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/redislen"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/rmqamqp"
 	"github.com/medal-labs/k8s-rmq-autoscaler/providers/rmqhttp"
+	"github.com/medal-labs/k8s-rmq-autoscaler/providers/rmqprometheus"
 )
 
 type Config struct {
 	RMQHTTP rmqhttp.Config
+	// RMQAMQP is enabled when its Url is set
+	RMQAMQP rmqamqp.Config
+	// RMQPrometheus is enabled when its Url is set
+	RMQPrometheus rmqprometheus.Config
+	// PromQL is enabled when its Url is set
+	PromQL promql.Config
+	// KafkaLag is enabled when its Brokers are set
+	KafkaLag kafkalag.Config
+	// RedisLen is enabled when its Options are set
+	RedisLen redislen.Config
 }
 
 func Configure(config Config) []provider.Config {
-	return []provider.Config{
+	configs := []provider.Config{
 		rmqhttp.ProviderConfig(config.RMQHTTP),
 	}
+	if len(config.RMQAMQP.Url) > 0 {
+		configs = append(configs, rmqamqp.ProviderConfig(config.RMQAMQP))
+	}
+	if len(config.RMQPrometheus.Url) > 0 {
+		configs = append(configs, rmqprometheus.ProviderConfig(config.RMQPrometheus))
+	}
+	if len(config.PromQL.Url) > 0 {
+		configs = append(configs, promql.ProviderConfig(config.PromQL))
+	}
+	if len(config.KafkaLag.Brokers) > 0 {
+		configs = append(configs, kafkalag.ProviderConfig(config.KafkaLag))
+	}
+	if config.RedisLen.Options != nil {
+		configs = append(configs, redislen.ProviderConfig(config.RedisLen))
+	}
+	return configs
 }
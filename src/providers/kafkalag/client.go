@@ -0,0 +1,191 @@
+package kafkalag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type kafkaClient struct {
+	*kafka.Client
+}
+
+// subscription is a consumer group reading a topic
+type subscription struct {
+	topic string
+	group string
+}
+
+type lagResult struct {
+	lag topicLag
+	err error
+}
+
+// consumerGroupsLag returns the lag of the consumer groups on each partition of their topics.
+// Metadata and offsets of all topics are requested once, committed offsets are requested once per group
+func (client kafkaClient) consumerGroupsLag(ctx context.Context, subscriptions map[subscription]bool) map[subscription]lagResult {
+	results := map[subscription]lagResult{}
+	failedTopics := map[string]bool{}
+	fail := func(err error, topic string) {
+		failedTopics[topic] = true
+		for s := range subscriptions {
+			if len(topic) == 0 || s.topic == topic {
+				results[s] = lagResult{err: err}
+			}
+		}
+	}
+	topicsSet := map[string]bool{}
+	for s := range subscriptions {
+		topicsSet[s.topic] = true
+	}
+	topics := make([]string, 0, len(topicsSet))
+	for topic := range topicsSet {
+		topics = append(topics, topic)
+	}
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: topics})
+	if err != nil {
+		fail(fmt.Errorf("failed to get topics metadata: %w", err), "")
+		return results
+	}
+	partitions := map[string][]int{}
+	offsetRequests := map[string][]kafka.OffsetRequest{}
+	for _, topic := range metadata.Topics {
+		if !topicsSet[topic.Name] {
+			continue
+		}
+		if topic.Error != nil {
+			fail(fmt.Errorf("failed to get topic metadata: %w", topic.Error), topic.Name)
+			continue
+		}
+		for _, partition := range topic.Partitions {
+			partitions[topic.Name] = append(partitions[topic.Name], partition.ID)
+			offsetRequests[topic.Name] = append(offsetRequests[topic.Name],
+				kafka.FirstOffsetOf(partition.ID), kafka.LastOffsetOf(partition.ID))
+		}
+	}
+	for topic := range topicsSet {
+		if _, ok := partitions[topic]; !ok && !failedTopics[topic] {
+			fail(fmt.Errorf("metadata of '%s' topic not found", topic), topic)
+		}
+	}
+	if len(offsetRequests) == 0 {
+		return results
+	}
+
+	var offsets *kafka.ListOffsetsResponse
+	var offsetsErr error
+	committed := map[string]committedOffsets{}
+	mx := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		offsets, offsetsErr = client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: offsetRequests})
+	}()
+	for group, groupTopics := range groupsTopics(subscriptions, partitions) {
+		wg.Add(1)
+		go func(group string, groupTopics map[string][]int) {
+			defer wg.Done()
+			offsets, err := client.committedOffsets(ctx, group, groupTopics)
+			mx.Lock()
+			committed[group] = committedOffsets{offsets: offsets, err: err}
+			mx.Unlock()
+		}(group, groupTopics)
+	}
+	wg.Wait()
+
+	if offsetsErr != nil {
+		fail(fmt.Errorf("failed to list offsets: %w", offsetsErr), "")
+		return results
+	}
+	for s := range subscriptions {
+		if _, failed := results[s]; failed {
+			continue
+		}
+		groupOffsets := committed[s.group]
+		if groupOffsets.err != nil {
+			results[s] = lagResult{err: groupOffsets.err}
+			continue
+		}
+		lag, err := partitionsLag(offsets.Topics[s.topic], groupOffsets.offsets[s.topic])
+		results[s] = lagResult{lag: lag, err: err}
+	}
+	return results
+}
+
+type committedOffsets struct {
+	// offsets are committed offsets by partitions of topics
+	offsets map[string]map[int]int64
+	err     error
+}
+
+// groupsTopics returns partitions of the topics read by each group
+func groupsTopics(subscriptions map[subscription]bool, partitions map[string][]int) map[string]map[string][]int {
+	groups := map[string]map[string][]int{}
+	for s := range subscriptions {
+		topicPartitions, ok := partitions[s.topic]
+		if !ok {
+			continue
+		}
+		if _, ok := groups[s.group]; !ok {
+			groups[s.group] = map[string][]int{}
+		}
+		groups[s.group][s.topic] = topicPartitions
+	}
+	return groups
+}
+
+func (client kafkaClient) committedOffsets(ctx context.Context, group string, topics map[string][]int) (map[string]map[int]int64, error) {
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group, Topics: topics})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get committed offsets: %w", err)
+	}
+	if committed.Error != nil {
+		return nil, fmt.Errorf("failed to get committed offsets: %w", committed.Error)
+	}
+	offsets := map[string]map[int]int64{}
+	for topic, topicPartitions := range committed.Topics {
+		offsets[topic] = map[int]int64{}
+		for _, partition := range topicPartitions {
+			if partition.Error != nil {
+				return nil, fmt.Errorf(
+					"failed to get committed offset of %d partition of '%s' topic: %w", partition.Partition, topic, partition.Error,
+				)
+			}
+			offsets[topic][partition.Partition] = partition.CommittedOffset
+		}
+	}
+	return offsets, nil
+}
+
+// partitionsLag computes the lag on each partition from its offsets and the committed ones.
+// Partitions without committed offsets are lagging by all their messages
+func partitionsLag(offsets []kafka.PartitionOffsets, committed map[int]int64) (topicLag, error) {
+	lag := topicLag{partitions: map[int]int64{}}
+	for _, partition := range offsets {
+		if partition.Error != nil {
+			return topicLag{}, fmt.Errorf("failed to list offsets of %d partition: %w", partition.Partition, partition.Error)
+		}
+		from, ok := committed[partition.Partition]
+		if !ok || from < 0 {
+			from = partition.FirstOffset
+		}
+		partitionLag := partition.LastOffset - from
+		if partitionLag < 0 {
+			partitionLag = 0
+		}
+		lag.partitions[partition.Partition] = partitionLag
+	}
+	return lag, nil
+}
+
+func (lag topicLag) total() int {
+	var total int64
+	for _, partitionLag := range lag.partitions {
+		total += partitionLag
+	}
+	return int(total)
+}
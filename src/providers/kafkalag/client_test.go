@@ -0,0 +1,81 @@
+package kafkalag
+
+import (
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPartitionsLag(t *testing.T) {
+	testCases := []struct {
+		name      string
+		offsets   []kafka.PartitionOffsets
+		committed map[int]int64
+		expected  map[int]int64
+		err       bool
+	}{
+		{
+			name: "committed offsets",
+			offsets: []kafka.PartitionOffsets{
+				{Partition: 0, FirstOffset: 0, LastOffset: 100},
+				{Partition: 1, FirstOffset: 10, LastOffset: 50},
+			},
+			committed: map[int]int64{0: 90, 1: 50},
+			expected:  map[int]int64{0: 10, 1: 0},
+		},
+		{
+			name:      "no committed offset",
+			offsets:   []kafka.PartitionOffsets{{Partition: 0, FirstOffset: 20, LastOffset: 100}},
+			committed: map[int]int64{},
+			expected:  map[int]int64{0: 80},
+		},
+		{
+			name:      "offset -1",
+			offsets:   []kafka.PartitionOffsets{{Partition: 0, FirstOffset: 20, LastOffset: 100}},
+			committed: map[int]int64{0: -1},
+			expected:  map[int]int64{0: 80},
+		},
+		{
+			name:      "negative lag",
+			offsets:   []kafka.PartitionOffsets{{Partition: 0, FirstOffset: 0, LastOffset: 100}},
+			committed: map[int]int64{0: 120},
+			expected:  map[int]int64{0: 0},
+		},
+		{
+			name: "partition error",
+			offsets: []kafka.PartitionOffsets{
+				{Partition: 0, FirstOffset: 0, LastOffset: 100},
+				{Partition: 1, Error: errors.New("leader not available")},
+			},
+			committed: map[int]int64{0: 90},
+			err:       true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			lag, err := partitionsLag(testCase.offsets, testCase.committed)
+			if testCase.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, lag.partitions)
+		})
+	}
+}
+
+func TestGroupsTopics(t *testing.T) {
+	subscriptions := map[subscription]bool{
+		{topic: "events", group: "worker"}:  true,
+		{topic: "orders", group: "worker"}:  true,
+		{topic: "events", group: "archive"}: true,
+		{topic: "missing", group: "worker"}: true,
+	}
+	partitions := map[string][]int{"events": {0, 1}, "orders": {0}}
+
+	require.Equal(t, map[string]map[string][]int{
+		"worker":  {"events": {0, 1}, "orders": {0}},
+		"archive": {"events": {0, 1}},
+	}, groupsTopics(subscriptions, partitions))
+}
@@ -0,0 +1,83 @@
+package kafkalag
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/segmentio/kafka-go"
+	"strconv"
+)
+
+// ProviderConfig returns configuration of the provider computing the lag of apps' Kafka consumer groups.
+// Total lag is provided as queue length, so queue based strategies can be used for Kafka consumers.
+// Lags of all apps are looked up together, so apps reading the same topic with the same group share it
+func ProviderConfig(config Config) provider.Config {
+	client := kafkaClient{
+		Client: &kafka.Client{
+			Addr:    kafka.TCP(config.Brokers...),
+			Timeout: config.Timeout,
+		},
+	}
+	return provider.Config{
+		Name: config.Name,
+		AvailableParameters: map[parameter.Name]parameter.Type{
+			parameters.QueueLength:  parameter.Int,
+			parameters.PartitionLag: parameter.IntMap,
+			parameters.Partitions:   parameter.Int,
+		},
+		Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+			subscriptions := map[subscription]bool{}
+			appsSubscriptions := map[scalable.App]subscription{}
+			for app, ctx := range appsCtx {
+				if ctx.IsCanceled() {
+					continue
+				}
+				var appConfig AppConfig
+				if err := app.ParseAnnotations(&appConfig, common.AnnotationPrefix); err != nil {
+					go ctx.Error(fmt.Errorf("failed to parse annotations: %w", err))
+					continue
+				}
+				s := subscription{topic: appConfig.Topic, group: appConfig.ConsumerGroup}
+				subscriptions[s] = true
+				appsSubscriptions[app] = s
+			}
+			if len(subscriptions) == 0 {
+				return
+			}
+			lagCtx, cancel := provider.SharedContext(config.Timeout, appsCtx)
+			defer cancel()
+			results := client.consumerGroupsLag(lagCtx, subscriptions)
+
+			for app, s := range appsSubscriptions {
+				go provideApp(appsCtx[app], results[s])
+			}
+		},
+	}
+}
+
+func provideApp(ctx provider.AppContext, result lagResult) {
+	if result.err != nil {
+		ctx.Error(fmt.Errorf("failed to get consumer group lag: %w", result.err))
+		return
+	}
+	params := provider.ProvidedParameters{}
+	for _, param := range ctx.Parameters {
+		switch param {
+		case parameters.QueueLength:
+			params.Set(parameters.QueueLength, result.lag.total())
+		case parameters.PartitionLag:
+			byPartition := map[string]int{}
+			for partition, partitionLag := range result.lag.partitions {
+				byPartition[strconv.Itoa(partition)] = int(partitionLag)
+			}
+			params.Set(parameters.PartitionLag, byPartition)
+		case parameters.Partitions:
+			params.Set(parameters.Partitions, len(result.lag.partitions))
+		}
+	}
+	ctx.PutResult(params)
+	ctx.Finish()
+}
@@ -0,0 +1,22 @@
+package kafkalag
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"time"
+)
+
+type Config struct {
+	Name    provider.Name
+	Brokers []string
+	Timeout time.Duration
+}
+
+type AppConfig struct {
+	Topic         string `k8s-annotation:"kafka-topic"`
+	ConsumerGroup string `k8s-annotation:"kafka-consumer-group"`
+}
+
+// topicLag is the lag of the consumer group on the topic
+type topicLag struct {
+	partitions map[int]int64
+}
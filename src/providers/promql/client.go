@@ -0,0 +1,89 @@
+package promql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type promClient struct {
+	*http.Client
+	config Config
+}
+
+// query evaluates the instant query, its result has to be a scalar or a vector with a single sample
+func (client promClient) query(query string) (float64, error) {
+	values := url.Values{}
+	values.Set("query", query)
+	if client.config.Timeout > 0 {
+		values.Set("timeout", client.config.Timeout.String())
+	}
+	req, err := http.NewRequest("GET", client.config.Url+"/api/v1/query?"+values.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	response, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	var result queryResponse
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("unexpected response with %s status: %w", response.Status, err)
+	}
+	if result.Status != "success" {
+		return 0, fmt.Errorf("query failed with %s error: %s", result.ErrorType, result.Error)
+	}
+	switch result.Data.ResultType {
+	case "scalar":
+		var sample sampleValue
+		if err := json.Unmarshal(result.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+		return sample.float()
+	case "vector":
+		var samples []vectorSample
+		if err := json.Unmarshal(result.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+		if len(samples) == 0 {
+			return 0, errNoData
+		}
+		if len(samples) > 1 {
+			return 0, fmt.Errorf("query returned %d samples, expected one", len(samples))
+		}
+		return samples[0].Value.float()
+	}
+	return 0, fmt.Errorf("unsupported '%s' result type", result.Data.ResultType)
+}
+
+var errNoData = errors.New("query returned no data")
+
+func (v sampleValue) float() (float64, error) {
+	s, ok := v[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed sample value %v", v[1])
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func newClient(config Config) promClient {
+	timeout := config.Timeout
+	if timeout > 0 {
+		// Prometheus is given the time to report the query timeout
+		timeout += time.Second
+	}
+	return promClient{
+		Client: &http.Client{Timeout: timeout},
+		config: config,
+	}
+}
@@ -0,0 +1,146 @@
+package promql
+
+import (
+	"fmt"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"k8s.io/klog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ProviderConfig returns configuration of the provider evaluating PromQL queries from apps annotations.
+// Queries are templates with app's '.Namespace', '.Name' and '.Queue' fields
+func ProviderConfig(config Config) provider.Config {
+	client := newClient(config)
+
+	mx := sync.Mutex{}
+	lastValues := map[string]lastValue{}
+
+	// evaluate returns the result of parameter's query falling back to its last value while it isn't stale
+	evaluate := func(app scalable.App, name parameter.Name) (float64, error) {
+		value, err := queryParameter(client, app, name)
+		cacheKey := app.Key + "/" + string(name)
+
+		mx.Lock()
+		defer mx.Unlock()
+		if err == nil {
+			lastValues[cacheKey] = lastValue{value: value, time: time.Now()}
+			return value, nil
+		}
+		last, ok := lastValues[cacheKey]
+		if !ok || time.Since(last.time) > config.Staleness {
+			delete(lastValues, cacheKey)
+			return 0, err
+		}
+		klog.Warningf("%s: '%s' parameter value from %s is used: %s", app.Key, name, last.time.Format(time.RFC3339), err)
+		return last.value, nil
+	}
+
+	return provider.Config{
+		Name:                config.Name,
+		AvailableParameters: config.Parameters,
+		Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+			for app, ctx := range appsCtx {
+				go func(app scalable.App, ctx provider.AppContext) {
+					if ctx.IsCanceled() {
+						return
+					}
+					params := provider.ProvidedParameters{}
+					for _, param := range ctx.Parameters {
+						value, err := evaluate(app, param)
+						if err != nil {
+							ctx.Error(fmt.Errorf("failed to evaluate '%s' parameter query: %w", param, err))
+							return
+						}
+						if config.Parameters[param].EqualTo(parameter.Int) {
+							params.Set(param, int(math.Round(value)))
+						} else {
+							params.Set(param, value)
+						}
+					}
+					ctx.PutResult(params)
+					ctx.Finish()
+				}(app, ctx)
+			}
+		},
+	}
+}
+
+// parsedTemplatesSize bounds the number of cached templates, so edits of annotations don't grow the cache
+const parsedTemplatesSize = 1024
+
+// parsedTemplates caches queries templates between executor rounds, they are keyed by the annotation value.
+// lru.New fails only for non-positive sizes
+var parsedTemplates, _ = lru.New(parsedTemplatesSize)
+
+func queryParameter(client promClient, app scalable.App, name parameter.Name) (float64, error) {
+	annotations := *app.Annotations
+	text, ok := annotations[common.AnnotationPrefix+string(name)+QueryAnnotationSuffix]
+	if !ok {
+		return 0, fmt.Errorf("'%s%s' annotation is not specified", name, QueryAnnotationSuffix)
+	}
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid query template: %w", err)
+	}
+	query := strings.Builder{}
+	data := queryData{
+		Namespace: escapeLabelValue(app.Namespace),
+		Name:      escapeLabelValue(app.Name),
+		Queue:     escapeLabelValue(annotations[common.AnnotationPrefix+"queue"]),
+	}
+	if err := tmpl.Execute(&query, data); err != nil {
+		return 0, fmt.Errorf("invalid query template: %w", err)
+	}
+	value, err := client.query(query.String())
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("query returned %f", value)
+	}
+	return value, nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	if cached, ok := parsedTemplates.Get(text); ok {
+		return cached.(*template.Template), nil
+	}
+	tmpl, err := template.New("query").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	parsedTemplates.Add(text, tmpl)
+	return tmpl, nil
+}
+
+// escapeLabelValue escapes the value to be used in a quoted label matcher
+func escapeLabelValue(value string) string {
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1]
+}
+
+// ParseParameters parses comma separated list of '<name>:<type>' parameters provided by queries
+func ParseParameters(s string) (map[parameter.Name]parameter.Type, error) {
+	params := map[parameter.Name]parameter.Type{}
+	for _, item := range common.SplitList(s) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed parameter declaration '%s', expected '<name>:<type>'", item)
+		}
+		paramType, ok := parameter.TypeByName(strings.TrimSpace(parts[1]))
+		if !ok || !(paramType.EqualTo(parameter.Int) || paramType.EqualTo(parameter.Float)) {
+			return nil, fmt.Errorf("unsupported type '%s' of '%s' parameter, expected 'int' or 'float'", parts[1], parts[0])
+		}
+		params[parameter.Name(strings.TrimSpace(parts[0]))] = paramType
+	}
+	return params, nil
+}
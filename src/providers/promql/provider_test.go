@@ -0,0 +1,139 @@
+package promql
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseParameters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected map[parameter.Name]parameter.Type
+		err      bool
+	}{
+		{name: "empty", value: "", expected: map[parameter.Name]parameter.Type{}},
+		{
+			name:  "int and float",
+			value: "backlog:int, lag-seconds : float",
+			expected: map[parameter.Name]parameter.Type{
+				"backlog":     parameter.Int,
+				"lag-seconds": parameter.Float,
+			},
+		},
+		{name: "missing type", value: "backlog", err: true},
+		{name: "unknown type", value: "backlog:number", err: true},
+		{name: "unsupported type", value: "backlog:string", err: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			params, err := ParseParameters(testCase.value)
+			if testCase.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, params, len(testCase.expected))
+			for name, paramType := range testCase.expected {
+				require.True(t, paramType.EqualTo(params[name]), "'%s' parameter type", name)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response string
+		expected float64
+		err      bool
+	}{
+		{
+			name:     "scalar",
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1591000000.123,"42.5"]}}`,
+			expected: 42.5,
+		},
+		{
+			name:     "single sample",
+			response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"queue":"jobs"},"value":[1591000000.123,"7"]}]}}`,
+			expected: 7,
+		},
+		{
+			name:     "no data",
+			response: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			err:      true,
+		},
+		{
+			name: "several samples",
+			response: `{"status":"success","data":{"resultType":"vector","result":[` +
+				`{"metric":{"queue":"a"},"value":[1591000000.123,"1"]},{"metric":{"queue":"b"},"value":[1591000000.123,"2"]}]}}`,
+			err: true,
+		},
+		{
+			name:     "matrix",
+			response: `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			err:      true,
+		},
+		{
+			name:     "query error",
+			response: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			err:      true,
+		},
+		{
+			name:     "malformed response",
+			response: `<html></html>`,
+			err:      true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(testCase.response))
+			}))
+			defer server.Close()
+
+			value, err := newClient(Config{Url: server.URL}).query("up")
+			if testCase.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, value)
+		})
+	}
+}
+
+func TestParseTemplate_cacheIsBounded(t *testing.T) {
+	for i := 0; i <= parsedTemplatesSize; i++ {
+		_, err := parseTemplate(fmt.Sprintf(`sum(queue_messages{queue="{{ .Queue }}"}) + %d`, i))
+		require.NoError(t, err)
+	}
+	require.Equal(t, parsedTemplatesSize, parsedTemplates.Len())
+}
+
+func TestQueryParameter_escapesLabelValues(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1591000000.123,"1"]}}`))
+	}))
+	defer server.Close()
+
+	app := scalable.App{
+		Namespace: "default",
+		Name:      "worker",
+		Annotations: &map[string]string{
+			common.AnnotationPrefix + "queue":                           `jobs"} or vector(1000) or {queue="\`,
+			common.AnnotationPrefix + "backlog" + QueryAnnotationSuffix: `sum(backlog{namespace="{{.Namespace}}",queue="{{.Queue}}"})`,
+		},
+	}
+	_, err := queryParameter(newClient(Config{Url: server.URL}), app, "backlog")
+	require.NoError(t, err)
+	require.Equal(t, `sum(backlog{namespace="default",queue="jobs\"} or vector(1000) or {queue=\"\\"})`, query)
+}
@@ -0,0 +1,52 @@
+package promql
+
+import (
+	"encoding/json"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"time"
+)
+
+type Config struct {
+	Name provider.Name
+	// Url of Prometheus HTTP API with scheme (Ex. http://prometheus:9090)
+	Url string
+	// Parameters are provided by queries from apps annotations named '<parameter>-query', only int and float types are supported
+	Parameters map[parameter.Name]parameter.Type
+	Timeout    time.Duration
+	// Staleness is how long the last value of a parameter is provided when its query fails or returns no data
+	Staleness time.Duration
+}
+
+// QueryAnnotationSuffix is appended to parameter's name to get the name of the annotation containing its query
+const QueryAnnotationSuffix = "-query"
+
+// queryData is available in queries templates, values are escaped for quoted label matchers
+type queryData struct {
+	Namespace string
+	Name      string
+	Queue     string
+}
+
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type vectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  sampleValue       `json:"value"`
+}
+
+// sampleValue is a pair of sample's timestamp and its value formatted as a string
+type sampleValue [2]interface{}
+
+type lastValue struct {
+	value float64
+	time  time.Time
+}
@@ -0,0 +1,242 @@
+package redislen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"k8s.io/klog"
+	"strconv"
+	"strings"
+)
+
+// ProviderConfig returns configuration of the provider reading lengths of Redis lists and streams.
+// Commands of all apps are sent in a single pipeline, entries not delivered to the consumer groups
+// are read up to the configured limit in a second one when Redis doesn't report the groups' lag
+func ProviderConfig(config Config) provider.Config {
+	client := redis.NewClient(config.Options)
+	if config.UndeliveredLimit <= 0 {
+		config.UndeliveredLimit = defaultUndeliveredLimit
+	}
+
+	return provider.Config{
+		Name: config.Name,
+		AvailableParameters: map[parameter.Name]parameter.Type{
+			parameters.QueueLength:            parameter.Int,
+			parameters.MessagesUnacknowledged: parameter.Int,
+		},
+		Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+			ctx, cancel := provider.SharedContext(config.Timeout, appsCtx)
+			defer cancel()
+			pipe := client.Pipeline()
+
+			commands := map[scalable.App]appCommands{}
+			for app, appCtx := range appsCtx {
+				if appCtx.IsCanceled() {
+					continue
+				}
+				cmds, err := queueCommands(ctx, pipe, app, appCtx.Parameters)
+				if err != nil {
+					go appCtx.Error(err)
+					continue
+				}
+				commands[app] = cmds
+			}
+			if len(commands) == 0 {
+				return
+			}
+			// Errors are checked for each command
+			_, _ = pipe.Exec(ctx)
+
+			undeliveredPipe := client.Pipeline()
+			for app, cmds := range commands {
+				if cmds.queueUndelivered(ctx, undeliveredPipe, config.UndeliveredLimit) {
+					commands[app] = cmds
+				}
+			}
+			if undeliveredPipe.Len() > 0 {
+				_, _ = undeliveredPipe.Exec(ctx)
+			}
+
+			for app, cmds := range commands {
+				go func(appCtx provider.AppContext, cmds appCommands) {
+					if appCtx.IsCanceled() {
+						return
+					}
+					params, err := cmds.parameters(appCtx.Parameters)
+					if err != nil {
+						appCtx.Error(err)
+						return
+					}
+					appCtx.PutResult(params)
+					appCtx.Finish()
+				}(appsCtx[app], cmds)
+			}
+		},
+	}
+}
+
+func queueCommands(ctx context.Context, pipe redis.Pipeliner, app scalable.App, params []parameter.Name) (appCommands, error) {
+	var appConfig AppConfig
+	if err := common.ParseK8sAnnotations(*app.Annotations, &appConfig, common.AnnotationPrefix); err != nil {
+		return appCommands{}, fmt.Errorf("failed to parse annotations: %w", err)
+	}
+	if (len(appConfig.List) == 0) == (len(appConfig.Stream) == 0) {
+		return appCommands{}, errors.New("either redis-list or redis-stream annotation has to be specified")
+	}
+	cmds := appCommands{config: appConfig}
+	for _, param := range params {
+		switch param {
+		case parameters.QueueLength:
+			switch {
+			case len(appConfig.List) > 0:
+				cmds.length = pipe.LLen(ctx, appConfig.List)
+			case len(appConfig.Group) == 0:
+				// All entries retained in the stream are counted, it has to be trimmed with MAXLEN or MINID
+				cmds.length = pipe.XLen(ctx, appConfig.Stream)
+			case cmds.groups == nil:
+				cmds.groups = pipe.Do(ctx, "xinfo", "groups", appConfig.Stream)
+			}
+		case parameters.MessagesUnacknowledged:
+			if len(appConfig.Stream) == 0 || len(appConfig.Group) == 0 {
+				return appCommands{}, errors.New("redis-stream and redis-group annotations are required for pending messages")
+			}
+			if cmds.groups == nil {
+				cmds.groups = pipe.Do(ctx, "xinfo", "groups", appConfig.Stream)
+			}
+		}
+	}
+	return cmds, nil
+}
+
+// queueUndelivered queues reading of at most limit entries after the last one delivered to the group
+// if the group's lag isn't reported, it returns whether the command was queued
+func (cmds *appCommands) queueUndelivered(ctx context.Context, pipe redis.Pipeliner, limit int64) bool {
+	if cmds.length != nil || cmds.groups == nil {
+		return false
+	}
+	group, err := cmds.streamGroup()
+	if err != nil || group.lag != nil {
+		return false
+	}
+	start, err := nextID(group.lastDeliveredID)
+	if err != nil {
+		return false
+	}
+	cmds.undelivered = pipe.XRangeN(ctx, cmds.config.Stream, start, "+", limit)
+	cmds.undeliveredLimit = limit
+	return true
+}
+
+func (cmds appCommands) parameters(params []parameter.Name) (provider.ProvidedParameters, error) {
+	provided := provider.ProvidedParameters{}
+	for _, param := range params {
+		switch param {
+		case parameters.QueueLength:
+			length, err := cmds.queueLength()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get length: %w", err)
+			}
+			provided.Set(parameters.QueueLength, int(length))
+		case parameters.MessagesUnacknowledged:
+			group, err := cmds.streamGroup()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get pending messages: %w", err)
+			}
+			provided.Set(parameters.MessagesUnacknowledged, int(group.pending))
+		}
+	}
+	return provided, nil
+}
+
+// queueLength returns the length of the list or the stream,
+// for a stream read by a consumer group it's the number of entries not delivered to the group and pending
+func (cmds appCommands) queueLength() (int64, error) {
+	if cmds.length != nil {
+		return cmds.length.Result()
+	}
+	group, err := cmds.streamGroup()
+	if err != nil {
+		return 0, err
+	}
+	if group.lag != nil {
+		return *group.lag + group.pending, nil
+	}
+	if cmds.undelivered == nil {
+		return 0, fmt.Errorf("malformed last delivered ID '%s'", group.lastDeliveredID)
+	}
+	undelivered, err := cmds.undelivered.Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read undelivered entries: %w", err)
+	}
+	if klog.V(2) && cmds.undeliveredLimit > 0 && int64(len(undelivered)) >= cmds.undeliveredLimit {
+		klog.Infof(
+			"'%s' stream has at least %d entries not delivered to '%s' group, the number is capped at the limit",
+			cmds.config.Stream, len(undelivered), cmds.config.Group,
+		)
+	}
+	return int64(len(undelivered)) + group.pending, nil
+}
+
+func (cmds appCommands) streamGroup() (streamGroup, error) {
+	reply, err := cmds.groups.Result()
+	if err != nil {
+		return streamGroup{}, fmt.Errorf("failed to get consumer groups of '%s' stream: %w", cmds.config.Stream, err)
+	}
+	return parseStreamGroup(reply, cmds.config.Group)
+}
+
+// parseStreamGroup returns the group from XINFO GROUPS reply
+func parseStreamGroup(reply interface{}, name string) (streamGroup, error) {
+	groups, ok := reply.([]interface{})
+	if !ok {
+		return streamGroup{}, fmt.Errorf("unexpected XINFO GROUPS reply %v", reply)
+	}
+	for _, groupReply := range groups {
+		fields, ok := groupReply.([]interface{})
+		if !ok || len(fields)%2 != 0 {
+			return streamGroup{}, fmt.Errorf("unexpected XINFO GROUPS reply %v", reply)
+		}
+		info := map[string]interface{}{}
+		for i := 0; i < len(fields); i += 2 {
+			key, ok := fields[i].(string)
+			if !ok {
+				return streamGroup{}, fmt.Errorf("unexpected XINFO GROUPS reply %v", reply)
+			}
+			info[key] = fields[i+1]
+		}
+		if info["name"] != name {
+			continue
+		}
+		group := streamGroup{}
+		if group.pending, ok = info["pending"].(int64); !ok {
+			return streamGroup{}, fmt.Errorf("unexpected pending entries %v of '%s' group", info["pending"], name)
+		}
+		if group.lastDeliveredID, ok = info["last-delivered-id"].(string); !ok {
+			return streamGroup{}, fmt.Errorf("unexpected last delivered ID %v of '%s' group", info["last-delivered-id"], name)
+		}
+		if lag, ok := info["lag"].(int64); ok {
+			group.lag = &lag
+		}
+		return group, nil
+	}
+	return streamGroup{}, fmt.Errorf("consumer group '%s' not found", name)
+}
+
+// nextID returns the smallest entry ID greater than the given one
+func nextID(id string) (string, error) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("malformed entry ID '%s'", id)
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("malformed entry ID '%s': %w", id, err)
+	}
+	return parts[0] + "-" + strconv.FormatUint(seq+1, 10), nil
+}
@@ -0,0 +1,306 @@
+package redislen
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers commands with replies by the command line, unknown commands are answered with an error
+type fakeRedis struct {
+	mx       sync.Mutex
+	replies  map[string]string
+	received []string
+}
+
+func (server *fakeRedis) serve(t *testing.T) *redis.Options {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+			go server.handle(conn)
+		}
+	}()
+	return &redis.Options{Addr: listener.Addr().String()}
+}
+
+func (server *fakeRedis) handle(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		server.mx.Lock()
+		server.received = append(server.received, command)
+		reply, ok := server.replies[command]
+		server.mx.Unlock()
+		if !ok {
+			reply = "-ERR unknown command '" + command + "'\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (server *fakeRedis) commands() []string {
+	server.mx.Lock()
+	defer server.mx.Unlock()
+	return append([]string(nil), server.received...)
+}
+
+// readCommand reads an array of bulk strings and returns them joined with spaces
+func readCommand(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return "", err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return "", err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return strings.Join(args, " "), nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func array(elements ...string) string {
+	return fmt.Sprintf("*%d\r\n", len(elements)) + strings.Join(elements, "")
+}
+
+// group6 is a group of XINFO GROUPS reply of Redis 6
+func group6(name string, pending int, lastDeliveredID string) string {
+	return array(
+		bulk("name"), bulk(name), bulk("consumers"), ":1\r\n",
+		bulk("pending"), fmt.Sprintf(":%d\r\n", pending), bulk("last-delivered-id"), bulk(lastDeliveredID),
+	)
+}
+
+// group7 is a group of XINFO GROUPS reply of Redis 7, lag is null when it's negative
+func group7(name string, pending int, lastDeliveredID string, lag int) string {
+	lagReply := fmt.Sprintf(":%d\r\n", lag)
+	if lag < 0 {
+		lagReply = "$-1\r\n"
+	}
+	return array(
+		bulk("name"), bulk(name), bulk("consumers"), ":1\r\n",
+		bulk("pending"), fmt.Sprintf(":%d\r\n", pending), bulk("last-delivered-id"), bulk(lastDeliveredID),
+		bulk("entries-read"), ":10\r\n", bulk("lag"), lagReply,
+	)
+}
+
+func entry(id string) string {
+	return array(bulk(id), array(bulk("job"), bulk("1")))
+}
+
+func testApp(annotations map[string]string) scalable.App {
+	prefixed := map[string]string{}
+	for name, value := range annotations {
+		prefixed[common.AnnotationPrefix+name] = value
+	}
+	return scalable.App{Namespace: "default", Name: "worker", Annotations: &prefixed}
+}
+
+func TestProvide(t *testing.T) {
+	both := []parameter.Name{parameters.QueueLength, parameters.MessagesUnacknowledged}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		params      []parameter.Name
+		replies     map[string]string
+		expected    provider.ProvidedParameters
+		err         bool
+	}{
+		{
+			name:        "list",
+			annotations: map[string]string{"redis-list": "jobs"},
+			params:      []parameter.Name{parameters.QueueLength},
+			replies:     map[string]string{"llen jobs": ":5\r\n"},
+			expected:    provider.ProvidedParameters{parameters.QueueLength: 5},
+		},
+		{
+			name:        "missing list",
+			annotations: map[string]string{"redis-list": "jobs"},
+			params:      []parameter.Name{parameters.QueueLength},
+			replies:     map[string]string{"llen jobs": ":0\r\n"},
+			expected:    provider.ProvidedParameters{parameters.QueueLength: 0},
+		},
+		{
+			name:        "stream without group",
+			annotations: map[string]string{"redis-stream": "events"},
+			params:      []parameter.Name{parameters.QueueLength},
+			replies:     map[string]string{"xlen events": ":7\r\n"},
+			expected:    provider.ProvidedParameters{parameters.QueueLength: 7},
+		},
+		{
+			name:        "group lag",
+			annotations: map[string]string{"redis-stream": "events", "redis-group": "worker"},
+			params:      both,
+			replies: map[string]string{
+				"xinfo groups events": array(group7("archive", 0, "1-0", 9), group7("worker", 2, "8-0", 3)),
+			},
+			expected: provider.ProvidedParameters{parameters.QueueLength: 5, parameters.MessagesUnacknowledged: 2},
+		},
+		{
+			name:        "group without lag",
+			annotations: map[string]string{"redis-stream": "events", "redis-group": "worker"},
+			params:      both,
+			replies: map[string]string{
+				"xinfo groups events":         array(group6("worker", 2, "8-0")),
+				"xrange events 8-1 + count 3": array(entry("9-0"), entry("10-0")),
+			},
+			expected: provider.ProvidedParameters{parameters.QueueLength: 4, parameters.MessagesUnacknowledged: 2},
+		},
+		{
+			name:        "unknown group lag",
+			annotations: map[string]string{"redis-stream": "events", "redis-group": "worker"},
+			params:      []parameter.Name{parameters.QueueLength},
+			replies: map[string]string{
+				"xinfo groups events":         array(group7("worker", 0, "8-3", -1)),
+				"xrange events 8-4 + count 3": array(entry("9-0")),
+			},
+			expected: provider.ProvidedParameters{parameters.QueueLength: 1},
+		},
+		{
+			name:        "undelivered entries limit",
+			annotations: map[string]string{"redis-stream": "events", "redis-group": "worker"},
+			params:      []parameter.Name{parameters.QueueLength},
+			replies: map[string]string{
+				"xinfo groups events":         array(group6("worker", 2, "8-0")),
+				"xrange events 8-1 + count 3": array(entry("9-0"), entry("10-0"), entry("11-0")),
+			},
+			expected: provider.ProvidedParameters{parameters.QueueLength: 5},
+		},
+		{
+			name:        "missing group",
+			annotations: map[string]string{"redis-stream": "events", "redis-group": "worker"},
+			params:      both,
+			replies:     map[string]string{"xinfo groups events": array(group7("archive", 0, "1-0", 9))},
+			err:         true,
+		},
+		{
+			name:        "missing stream",
+			annotations: map[string]string{"redis-stream": "events", "redis-group": "worker"},
+			params:      both,
+			replies:     map[string]string{"xinfo groups events": "-ERR no such key\r\n"},
+			err:         true,
+		},
+		{
+			name:        "pending messages without group",
+			annotations: map[string]string{"redis-stream": "events"},
+			params:      []parameter.Name{parameters.MessagesUnacknowledged},
+			err:         true,
+		},
+		{
+			name:        "list and stream",
+			annotations: map[string]string{"redis-list": "jobs", "redis-stream": "events"},
+			params:      []parameter.Name{parameters.QueueLength},
+			err:         true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := &fakeRedis{replies: testCase.replies}
+			config := ProviderConfig(Config{
+				Name:             "redis-length-provider",
+				Options:          server.serve(t),
+				Timeout:          5 * time.Second,
+				UndeliveredLimit: 3,
+			})
+			app := testApp(testCase.annotations)
+
+			resultCtx := provider.Launch(config, map[scalable.App][]parameter.Name{app: testCase.params})[app]
+			result, ok := resultCtx.GetNextResult()
+			require.True(t, ok)
+			if testCase.err {
+				require.Error(t, result.Error)
+				return
+			}
+			require.NoError(t, result.Error)
+			require.Equal(t, testCase.expected, result.Parameters)
+		})
+	}
+}
+
+func TestProvide_canceledApp(t *testing.T) {
+	server := &fakeRedis{replies: map[string]string{"llen jobs": ":5\r\n"}}
+	config := ProviderConfig(Config{Name: "redis-length-provider", Options: server.serve(t)})
+	canceled := testApp(map[string]string{"redis-list": "canceled"})
+	app := testApp(map[string]string{"redis-list": "jobs"})
+	app.Name = "other-worker"
+
+	provided := make(chan struct{})
+	provide := config.Provide
+	config.Provide = func(appsCtx map[scalable.App]provider.AppContext) {
+		appsCtx[canceled].Finish()
+		provide(appsCtx)
+		close(provided)
+	}
+	params := []parameter.Name{parameters.QueueLength}
+	resultsCtx := provider.Launch(config, map[scalable.App][]parameter.Name{canceled: params, app: params})
+
+	result, ok := resultsCtx[app].GetNextResult()
+	require.True(t, ok)
+	require.NoError(t, result.Error)
+	require.Equal(t, provider.ProvidedParameters{parameters.QueueLength: 5}, result.Parameters)
+	<-provided
+	_, ok = resultsCtx[canceled].GetNextResult()
+	require.False(t, ok)
+	require.Equal(t, []string{"llen jobs"}, server.commands())
+}
+
+func TestNextID(t *testing.T) {
+	testCases := []struct {
+		id       string
+		expected string
+		err      bool
+	}{
+		{id: "0-0", expected: "0-1"},
+		{id: "1526919030474-55", expected: "1526919030474-56"},
+		{id: "1526919030474", err: true},
+		{id: "1526919030474-x", err: true},
+	}
+	for _, testCase := range testCases {
+		id, err := nextID(testCase.id)
+		if testCase.err {
+			require.Error(t, err, testCase.id)
+			continue
+		}
+		require.NoError(t, err, testCase.id)
+		require.Equal(t, testCase.expected, id, testCase.id)
+	}
+}
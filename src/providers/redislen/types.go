@@ -0,0 +1,46 @@
+package redislen
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"time"
+)
+
+const defaultUndeliveredLimit = 1000
+
+type Config struct {
+	Name    provider.Name
+	Options *redis.Options
+	Timeout time.Duration
+	// UndeliveredLimit is the maximum number of entries read after the last delivered one when the group's lag
+	// isn't reported, the stream is considered to have this number of undelivered entries when it's reached
+	UndeliveredLimit int64
+}
+
+// AppConfig contains either list or stream, group is required for the number of pending messages of the stream
+type AppConfig struct {
+	List   string `k8s-annotation:"redis-list" default:""`
+	Stream string `k8s-annotation:"redis-stream" default:""`
+	Group  string `k8s-annotation:"redis-group" default:""`
+}
+
+// appCommands are the commands queued to the pipeline for an app
+type appCommands struct {
+	config AppConfig
+	// length is the length of the list or the stream without group
+	length *redis.IntCmd
+	// groups is XINFO GROUPS reply of the stream read by the group
+	groups *redis.Cmd
+	// undelivered are the entries after the last one delivered to the group when its lag isn't reported
+	undelivered      *redis.XMessageSliceCmd
+	undeliveredLimit int64
+}
+
+// streamGroup is a consumer group of a stream
+type streamGroup struct {
+	pending         int64
+	lastDeliveredID string
+	// lag is the number of entries not delivered to the group yet, it's reported since Redis 7
+	// unless entries were deleted from the middle of the stream
+	lag *int64
+}
@@ -0,0 +1,195 @@
+package rmqamqp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"k8s.io/klog"
+)
+
+// amqpClient keeps a connection and a channel for each vhost, they are reopened when closed by errors
+type amqpClient struct {
+	config Config
+
+	mx     sync.Mutex
+	vhosts map[string]*vhostConnection
+}
+
+type vhostConnection struct {
+	mx            sync.Mutex
+	conn          *amqp.Connection
+	channel       *amqp.Channel
+	channelClosed chan *amqp.Error
+
+	// socket and blocking state are accessed without mx, as the connection is aborted while an operation holds it
+	socketMx sync.Mutex
+	socket   net.Conn
+	blocked  *amqp.Blocking
+}
+
+type inspection struct {
+	info amqp.Queue
+	err  error
+}
+
+func newClient(config Config) *amqpClient {
+	return &amqpClient{
+		config: config,
+		vhosts: map[string]*vhostConnection{},
+	}
+}
+
+// inspectQueue returns queue's number of ready messages and consumers using passive declare.
+// It returns when done is closed, the connection is aborted if the inspection doesn't finish in time
+func (client *amqpClient) inspectQueue(done <-chan struct{}, queue string, vhost string) (amqp.Queue, error) {
+	vhost, err := url.PathUnescape(vhost)
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+	conn := client.vhostConnection(vhost)
+
+	result := make(chan inspection, 1)
+	go func() {
+		conn.mx.Lock()
+		defer conn.mx.Unlock()
+
+		if client.config.Timeout > 0 {
+			abort := time.AfterFunc(client.config.Timeout, conn.abort)
+			defer abort.Stop()
+		}
+		info, err := conn.inspect(client.config, vhost, queue)
+		result <- inspection{info: info, err: err}
+	}()
+	select {
+	case r := <-result:
+		return r.info, r.err
+	case <-done:
+		return amqp.Queue{}, errors.New("queue inspection is canceled")
+	}
+}
+
+func (client *amqpClient) vhostConnection(vhost string) *vhostConnection {
+	client.mx.Lock()
+	defer client.mx.Unlock()
+
+	conn, ok := client.vhosts[vhost]
+	if !ok {
+		conn = &vhostConnection{}
+		client.vhosts[vhost] = conn
+	}
+	return conn
+}
+
+func (conn *vhostConnection) inspect(config Config, vhost string, queue string) (amqp.Queue, error) {
+	channel, err := conn.open(config, vhost)
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+	if blocked := conn.blocking(); blocked != nil {
+		return amqp.Queue{}, fmt.Errorf("connection is blocked by the broker: %s", blocked.Reason)
+	}
+	info, err := channel.QueueDeclarePassive(queue, false, false, false, false, nil)
+	if err != nil {
+		// Channel is closed by the broker on failed declare, e.g. when the queue doesn't exist
+		_ = channel.Close()
+		conn.channel = nil
+		return amqp.Queue{}, err
+	}
+	return info, nil
+}
+
+// open returns the channel of the vhost opening the connection and the channel if needed
+func (conn *vhostConnection) open(config Config, vhost string) (*amqp.Channel, error) {
+	if conn.conn == nil || conn.conn.IsClosed() {
+		amqpConfig := amqp.Config{Vhost: vhost, Dial: conn.dial(config.Timeout)}
+		if len(config.User) > 0 {
+			amqpConfig.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: config.User, Password: config.Password}}
+		}
+		c, err := amqp.DialConfig(config.Url, amqpConfig)
+		if err != nil {
+			return nil, err
+		}
+		conn.conn = c
+		conn.channel = nil
+		conn.watch(c, vhost)
+	}
+	if conn.channel != nil {
+		select {
+		case <-conn.channelClosed:
+			conn.channel = nil
+		default:
+		}
+	}
+	if conn.channel == nil {
+		channel, err := conn.conn.Channel()
+		if err != nil {
+			_ = conn.conn.Close()
+			return nil, err
+		}
+		conn.channel = channel
+		conn.channelClosed = channel.NotifyClose(make(chan *amqp.Error, 1))
+	}
+	return conn.channel, nil
+}
+
+// dial opens the socket with the timeout, it's also the deadline of connection's handshake
+func (conn *vhostConnection) dial(timeout time.Duration) func(network, addr string) (net.Conn, error) {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	dial := amqp.DefaultDial(timeout)
+
+	return func(network, addr string) (net.Conn, error) {
+		socket, err := dial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		conn.socketMx.Lock()
+		conn.socket = socket
+		conn.blocked = nil
+		conn.socketMx.Unlock()
+		return socket, nil
+	}
+}
+
+// watch tracks blocking of the connection and logs its closing
+func (conn *vhostConnection) watch(c *amqp.Connection, vhost string) {
+	blockings := c.NotifyBlocked(make(chan amqp.Blocking, 1))
+	closed := c.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		for blocking := range blockings {
+			conn.socketMx.Lock()
+			if blocking.Active {
+				conn.blocked = &blocking
+			} else {
+				conn.blocked = nil
+			}
+			conn.socketMx.Unlock()
+		}
+	}()
+	go func() {
+		for err := range closed {
+			klog.Warningf("AMQP connection to '%s' vhost is closed: %s", vhost, err)
+		}
+	}()
+}
+
+func (conn *vhostConnection) blocking() *amqp.Blocking {
+	conn.socketMx.Lock()
+	defer conn.socketMx.Unlock()
+	return conn.blocked
+}
+
+// abort closes the socket, so that pending operations fail and the connection is reopened
+func (conn *vhostConnection) abort() {
+	conn.socketMx.Lock()
+	defer conn.socketMx.Unlock()
+	if conn.socket != nil {
+		_ = conn.socket.Close()
+	}
+}
@@ -0,0 +1,50 @@
+package rmqamqp
+
+import (
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+	"time"
+)
+
+// silentBroker accepts connections and never answers
+func silentBroker(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+	return "amqp://" + listener.Addr().String()
+}
+
+func TestInspectQueue_unresponsiveBroker(t *testing.T) {
+	testCases := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+	}{
+		{name: "timed out", timeout: 100 * time.Millisecond},
+		{name: "canceled", timeout: time.Minute, cancel: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newClient(Config{Url: silentBroker(t), Timeout: testCase.timeout})
+			done := make(chan struct{})
+			if testCase.cancel {
+				time.AfterFunc(100*time.Millisecond, func() { close(done) })
+			}
+			start := time.Now()
+			_, err := client.inspectQueue(done, "jobs", "%2F")
+			require.Error(t, err)
+			require.Less(t, int64(time.Since(start)), int64(5*time.Second))
+		})
+	}
+}
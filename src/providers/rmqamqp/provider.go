@@ -0,0 +1,56 @@
+package rmqamqp
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+)
+
+// ProviderConfig returns configuration of the provider reading queues state over AMQP 0-9-1.
+// Queue length provided by it is the number of ready messages, unacknowledged ones aren't included
+func ProviderConfig(config Config) provider.Config {
+	client := newClient(config)
+
+	return provider.Config{
+		Name: config.Name,
+		AvailableParameters: map[parameter.Name]parameter.Type{
+			parameters.QueueLength: parameter.Int,
+			parameters.Consumers:   parameter.Int,
+		},
+		Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+			for app, ctx := range appsCtx {
+				go func(app scalable.App, ctx provider.AppContext) {
+					if ctx.IsCanceled() {
+						return
+					}
+					var appConfig AppConfig
+					if err := app.ParseAnnotations(&appConfig, common.AnnotationPrefix); err != nil {
+						err = fmt.Errorf("failed to parse annotations: %w", err)
+						ctx.Error(err)
+						return
+					}
+					info, err := client.inspectQueue(ctx.Done(), appConfig.QueueName, appConfig.Vhost)
+					if err != nil {
+						err = fmt.Errorf("failed to inspect queue: %w", err)
+						ctx.Error(err)
+						return
+					}
+					params := provider.ProvidedParameters{}
+					for _, param := range ctx.Parameters {
+						switch param {
+						case parameters.QueueLength:
+							params.Set(parameters.QueueLength, info.Messages)
+						case parameters.Consumers:
+							params.Set(parameters.Consumers, info.Consumers)
+						}
+					}
+					ctx.PutResult(params)
+					ctx.Finish()
+				}(app, ctx)
+			}
+		},
+	}
+}
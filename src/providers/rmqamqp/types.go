@@ -0,0 +1,21 @@
+package rmqamqp
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"time"
+)
+
+type Config struct {
+	Name provider.Name
+	// Url is AMQP URI of the broker, vhost is selected by apps annotations
+	Url      string
+	User     string
+	Password string
+	// Timeout bounds dialing and each queue inspection, the connection is reopened after a timed out inspection
+	Timeout time.Duration
+}
+
+type AppConfig struct {
+	QueueName string `k8s-annotation:"queue"`
+	Vhost     string `k8s-annotation:"vhost"`
+}
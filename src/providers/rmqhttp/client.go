@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 )
 
 type rmqHTTPClient struct {
@@ -12,19 +13,66 @@ type rmqHTTPClient struct {
 }
 
 func (client rmqHTTPClient) getQueueInfo(queue string, vhost string) (*QueueInfo, error) {
-	reqUrl := fmt.Sprintf("%s/api/queues/%s/%s", client.config.Url, vhost, queue)
-	req, err := http.NewRequest("GET", reqUrl, nil)
-	req.SetBasicAuth(client.config.User, client.config.Password)
-	if err != nil {
+	var info QueueInfo
+	if err := client.get(fmt.Sprintf("/api/queues/%s/%s", vhost, queue), &info); err != nil {
 		return nil, err
 	}
-	response, err := client.Do(req)
-	if err != nil || response.StatusCode != http.StatusOK {
+	return &info, nil
+}
+
+func (client rmqHTTPClient) getChannels(vhost string) ([]ChannelInfo, error) {
+	var channels []ChannelInfo
+	if err := client.get(fmt.Sprintf("/api/vhosts/%s/channels", vhost), &channels); err != nil {
 		return nil, err
 	}
-	var info QueueInfo
-	if err := json.NewDecoder(response.Body).Decode(&info); err != nil {
-		return nil, err
+	return channels, nil
+}
+
+func (client rmqHTTPClient) get(path string, v interface{}) error {
+	req, err := http.NewRequest("GET", client.config.Url+path, nil)
+	if err != nil {
+		return err
 	}
-	return &info, nil
+	req.SetBasicAuth(client.config.User, client.config.Password)
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// vhostChannels fetches channels of each vhost once and shares them between apps of the vhost
+type vhostChannels struct {
+	client  rmqHTTPClient
+	mx      sync.Mutex
+	fetched map[string]*fetchedChannels
+}
+
+type fetchedChannels struct {
+	once     sync.Once
+	channels []ChannelInfo
+	err      error
+}
+
+func newVhostChannels(client rmqHTTPClient) *vhostChannels {
+	return &vhostChannels{client: client, fetched: map[string]*fetchedChannels{}}
+}
+
+func (c *vhostChannels) get(vhost string) ([]ChannelInfo, error) {
+	c.mx.Lock()
+	fetched, ok := c.fetched[vhost]
+	if !ok {
+		fetched = &fetchedChannels{}
+		c.fetched[vhost] = fetched
+	}
+	c.mx.Unlock()
+
+	fetched.once.Do(func() {
+		fetched.channels, fetched.err = c.client.getChannels(vhost)
+	})
+	return fetched.channels, fetched.err
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/medal-labs/k8s-rmq-autoscaler/common"
 	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
 	"net/http"
+	"time"
 )
 
 func ProviderConfig(config Config) provider.Config {
@@ -18,9 +19,17 @@ func ProviderConfig(config Config) provider.Config {
 	return provider.Config{
 		Name: config.Name,
 		AvailableParameters: map[parameter.Name]parameter.Type{
-			parameters.QueueLength: parameter.Int,
+			parameters.QueueLength:            parameter.Int,
+			parameters.HeadMessageAge:         parameter.Duration,
+			parameters.PublishRate:            parameter.Float,
+			parameters.DeliverRate:            parameter.Float,
+			parameters.MessagesUnacknowledged: parameter.Int,
+			parameters.ConsumersInFlight:      parameter.IntMap,
+			parameters.Consumers:              parameter.Int,
+			parameters.MessagesReady:          parameter.Int,
 		},
 		Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+			channels := newVhostChannels(client)
 			for app, ctx := range appsCtx {
 				go func(app scalable.App, ctx provider.AppContext) {
 					if ctx.IsCanceled() {
@@ -43,6 +52,25 @@ func ProviderConfig(config Config) provider.Config {
 						switch param {
 						case parameters.QueueLength:
 							params.Set(parameters.QueueLength, info.Messages)
+						case parameters.HeadMessageAge:
+							params.Set(parameters.HeadMessageAge, info.headMessageAge())
+						case parameters.PublishRate:
+							params.Set(parameters.PublishRate, info.MessageStats.PublishDetails.Rate)
+						case parameters.DeliverRate:
+							params.Set(parameters.DeliverRate, info.MessageStats.DeliverGetDetails.Rate)
+						case parameters.Consumers:
+							params.Set(parameters.Consumers, info.Consumers)
+						case parameters.MessagesUnacknowledged:
+							params.Set(parameters.MessagesUnacknowledged, info.MessagesUnacknowledged)
+						case parameters.MessagesReady:
+							params.Set(parameters.MessagesReady, info.MessagesReady)
+						case parameters.ConsumersInFlight:
+							vhostChannels, err := channels.get(appConfig.Vhost)
+							if err != nil {
+								ctx.Error(fmt.Errorf("failed to get channels info: %w", err))
+								return
+							}
+							params.Set(parameters.ConsumersInFlight, info.inFlightByHost(vhostChannels))
 						}
 					}
 					ctx.PutResult(params)
@@ -52,3 +80,38 @@ func ProviderConfig(config Config) provider.Config {
 		},
 	}
 }
+
+func (info QueueInfo) headMessageAge() time.Duration {
+	if info.HeadMessageTimestamp == nil || *info.HeadMessageTimestamp <= 0 {
+		return 0
+	}
+	age := time.Since(time.Unix(*info.HeadMessageTimestamp, 0))
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// inFlightByHost sums unacknowledged messages of the queue consumers' channels by consumers' hosts.
+// Hosts of idle consumers are included with zero value
+func (info QueueInfo) inFlightByHost(channels []ChannelInfo) map[string]int {
+	unacknowledged := map[string]int{}
+	for _, channel := range channels {
+		unacknowledged[channel.Name] = channel.MessagesUnacknowledged
+	}
+	inFlight := map[string]int{}
+	counted := map[string]bool{}
+	for _, consumer := range info.ConsumerDetails {
+		host := consumer.ChannelDetails.PeerHost
+		if _, ok := inFlight[host]; !ok {
+			inFlight[host] = 0
+		}
+		// Several consumers can share a channel
+		if counted[consumer.ChannelDetails.Name] {
+			continue
+		}
+		counted[consumer.ChannelDetails.Name] = true
+		inFlight[host] += unacknowledged[consumer.ChannelDetails.Name]
+	}
+	return inFlight
+}
@@ -0,0 +1,72 @@
+package rmqhttp
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQueueInfo_inFlightByHost(t *testing.T) {
+	var info QueueInfo
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"consumer_details": [
+			{"consumer_tag": "a", "channel_details": {"name": "ch-1", "peer_host": "10.0.0.1"}},
+			{"consumer_tag": "b", "channel_details": {"name": "ch-1", "peer_host": "10.0.0.1"}},
+			{"consumer_tag": "c", "channel_details": {"name": "ch-2", "peer_host": "10.0.0.1"}},
+			{"consumer_tag": "d", "channel_details": {"name": "ch-3", "peer_host": "10.0.0.2"}},
+			{"consumer_tag": "e", "channel_details": {"name": "ch-4", "peer_host": "10.0.0.3"}}
+		]
+	}`), &info))
+	channels := []ChannelInfo{
+		{Name: "ch-1", MessagesUnacknowledged: 2},
+		{Name: "ch-2", MessagesUnacknowledged: 3},
+		{Name: "ch-3", MessagesUnacknowledged: 0},
+		{Name: "ch-5", MessagesUnacknowledged: 7},
+	}
+	require.Equal(t,
+		map[string]int{"10.0.0.1": 5, "10.0.0.2": 0, "10.0.0.3": 0},
+		info.inFlightByHost(channels),
+		"Expected shared channels to be counted once and channels of other queues to be ignored",
+	)
+}
+
+func TestVhostChannels(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/api/vhosts/missing/channels" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"name": "ch-1", "messages_unacknowledged": 1}]`))
+	}))
+	defer server.Close()
+
+	channels := newVhostChannels(rmqHTTPClient{Client: server.Client(), config: Config{Url: server.URL}})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetched, err := channels.get("vhost")
+			if err == nil && len(fetched) != 1 {
+				t.Errorf("unexpected channels: %v", fetched)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests), "Expected channels of vhost to be fetched once")
+
+	_, err := channels.get("missing")
+	require.Error(t, err)
+	_, err = channels.get("missing")
+	require.Error(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
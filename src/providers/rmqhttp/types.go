@@ -17,10 +17,12 @@ type AppConfig struct {
 }
 
 type QueueInfo struct {
-	Consumers       int    `json:"consumers"`
-	IdleSince       string `json:"idle_since"`
-	Messages        int    `json:"messages"`
-	MessagesDetails struct {
+	Consumers            int            `json:"consumers"`
+	ConsumerDetails      []ConsumerInfo `json:"consumer_details"`
+	HeadMessageTimestamp *int64         `json:"head_message_timestamp"`
+	IdleSince            string         `json:"idle_since"`
+	Messages             int            `json:"messages"`
+	MessagesDetails      struct {
 		Rate float64 `json:"rate"`
 	} `json:"messages_details"`
 	MessagesReady        int `json:"messages_ready"`
@@ -31,8 +33,29 @@ type QueueInfo struct {
 	MessagesUnacknowledgedDetails struct {
 		Rate float64 `json:"rate"`
 	} `json:"messages_unacknowledged_details"`
+	MessageStats struct {
+		PublishDetails struct {
+			Rate float64 `json:"rate"`
+		} `json:"publish_details"`
+		DeliverGetDetails struct {
+			Rate float64 `json:"rate"`
+		} `json:"deliver_get_details"`
+	} `json:"message_stats"`
 	Name  string `json:"name"`
 	Node  string `json:"node"`
 	State string `json:"state"`
 	Vhost string `json:"vhost"`
 }
+
+type ConsumerInfo struct {
+	ConsumerTag    string `json:"consumer_tag"`
+	ChannelDetails struct {
+		Name     string `json:"name"`
+		PeerHost string `json:"peer_host"`
+	} `json:"channel_details"`
+}
+
+type ChannelInfo struct {
+	Name                   string `json:"name"`
+	MessagesUnacknowledged int    `json:"messages_unacknowledged"`
+}
@@ -0,0 +1,90 @@
+package rmqprometheus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const metricsPath = "/metrics/detailed?family=queue_coarse_metrics&family=queue_consumer_count"
+
+const (
+	messagesMetric       = "rabbitmq_detailed_queue_messages"
+	readyMetric          = "rabbitmq_detailed_queue_messages_ready"
+	unacknowledgedMetric = "rabbitmq_detailed_queue_messages_unacked"
+	consumersMetric      = "rabbitmq_detailed_queue_consumers"
+)
+
+type prometheusClient struct {
+	*http.Client
+	config Config
+}
+
+// scrape returns metrics of all queues of the broker
+func (client prometheusClient) scrape() (map[queueKey]queueMetrics, error) {
+	req, err := http.NewRequest("GET", client.config.Url+metricsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.FmtText))
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	return parseMetrics(response.Body)
+}
+
+func parseMetrics(r io.Reader) (map[queueKey]queueMetrics, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+	queues := map[queueKey]queueMetrics{}
+
+	set := func(name string, field func(m *queueMetrics, value int)) {
+		family, ok := families[name]
+		if !ok {
+			return
+		}
+		for _, metric := range family.Metric {
+			key := queueKey{}
+			for _, label := range metric.Label {
+				switch label.GetName() {
+				case "vhost":
+					key.vhost = label.GetValue()
+				case "queue":
+					key.queue = label.GetValue()
+				}
+			}
+			m := queues[key]
+			field(&m, int(metricValue(metric)))
+			queues[key] = m
+		}
+	}
+	set(messagesMetric, func(m *queueMetrics, value int) { m.messages = value })
+	set(readyMetric, func(m *queueMetrics, value int) { m.ready = value })
+	set(unacknowledgedMetric, func(m *queueMetrics, value int) { m.unacknowledged = value })
+	set(consumersMetric, func(m *queueMetrics, value int) { m.consumers = value })
+
+	return queues, nil
+}
+
+func metricValue(metric *dto.Metric) float64 {
+	switch {
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue()
+	case metric.Counter != nil:
+		return metric.Counter.GetValue()
+	case metric.Untyped != nil:
+		return metric.Untyped.GetValue()
+	}
+	return 0
+}
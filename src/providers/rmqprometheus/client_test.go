@@ -0,0 +1,44 @@
+package rmqprometheus
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// detailedMetrics is a response of '/metrics/detailed?family=queue_coarse_metrics&family=queue_consumer_count'
+const detailedMetrics = `# TYPE rabbitmq_detailed_queue_messages_ready gauge
+# HELP rabbitmq_detailed_queue_messages_ready Messages ready to be delivered to consumers
+rabbitmq_detailed_queue_messages_ready{vhost="/",queue="jobs"} 12
+rabbitmq_detailed_queue_messages_ready{vhost="orders",queue="created"} 0
+# TYPE rabbitmq_detailed_queue_messages_unacked gauge
+# HELP rabbitmq_detailed_queue_messages_unacked Messages delivered to consumers but not yet acknowledged
+rabbitmq_detailed_queue_messages_unacked{vhost="/",queue="jobs"} 3
+rabbitmq_detailed_queue_messages_unacked{vhost="orders",queue="created"} 1
+# TYPE rabbitmq_detailed_queue_messages gauge
+# HELP rabbitmq_detailed_queue_messages Sum of ready and unacknowledged messages - total queue depth
+rabbitmq_detailed_queue_messages{vhost="/",queue="jobs"} 15
+rabbitmq_detailed_queue_messages{vhost="orders",queue="created"} 1
+# TYPE rabbitmq_detailed_queue_process_reductions_total counter
+# HELP rabbitmq_detailed_queue_process_reductions_total Total number of queue process reductions
+rabbitmq_detailed_queue_process_reductions_total{vhost="/",queue="jobs"} 1840221
+rabbitmq_detailed_queue_process_reductions_total{vhost="orders",queue="created"} 96530
+# TYPE rabbitmq_detailed_queue_consumers gauge
+# HELP rabbitmq_detailed_queue_consumers Consumers on a queue
+rabbitmq_detailed_queue_consumers{vhost="/",queue="jobs"} 4
+rabbitmq_detailed_queue_consumers{vhost="orders",queue="created"} 2
+`
+
+func TestParseMetrics(t *testing.T) {
+	queues, err := parseMetrics(strings.NewReader(detailedMetrics))
+	require.NoError(t, err)
+	require.Equal(t, map[queueKey]queueMetrics{
+		{vhost: "/", queue: "jobs"}:         {messages: 15, ready: 12, unacknowledged: 3, consumers: 4},
+		{vhost: "orders", queue: "created"}: {messages: 1, ready: 0, unacknowledged: 1, consumers: 2},
+	}, queues)
+}
+
+func TestParseMetrics_malformed(t *testing.T) {
+	_, err := parseMetrics(strings.NewReader(`rabbitmq_detailed_queue_messages{vhost="/",queue="jobs" 15`))
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package rmqprometheus
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"net/http"
+	"net/url"
+)
+
+// ProviderConfig returns configuration of the provider reading queues metrics from rabbitmq_prometheus plugin.
+// Metrics of all queues are scraped once for all apps
+func ProviderConfig(config Config) provider.Config {
+	client := prometheusClient{
+		Client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+	return provider.Config{
+		Name: config.Name,
+		AvailableParameters: map[parameter.Name]parameter.Type{
+			parameters.QueueLength:            parameter.Int,
+			parameters.Consumers:              parameter.Int,
+			parameters.MessagesReady:          parameter.Int,
+			parameters.MessagesUnacknowledged: parameter.Int,
+		},
+		Provide: func(appsCtx map[scalable.App]provider.AppContext) {
+			queues, err := client.scrape()
+			if err != nil {
+				err = fmt.Errorf("failed to scrape queues metrics: %w", err)
+			}
+			for app, ctx := range appsCtx {
+				go func(app scalable.App, ctx provider.AppContext) {
+					if ctx.IsCanceled() {
+						return
+					}
+					if err != nil {
+						ctx.Error(err)
+						return
+					}
+					provideApp(app, ctx, queues)
+				}(app, ctx)
+			}
+		},
+	}
+}
+
+func provideApp(app scalable.App, ctx provider.AppContext, queues map[queueKey]queueMetrics) {
+	var appConfig AppConfig
+	if err := app.ParseAnnotations(&appConfig, common.AnnotationPrefix); err != nil {
+		err = fmt.Errorf("failed to parse annotations: %w", err)
+		ctx.Error(err)
+		return
+	}
+	vhost, err := url.PathUnescape(appConfig.Vhost)
+	if err != nil {
+		ctx.Error(fmt.Errorf("invalid vhost: %w", err))
+		return
+	}
+	metrics, ok := queues[queueKey{vhost: vhost, queue: appConfig.QueueName}]
+	if !ok {
+		ctx.Error(fmt.Errorf("metrics of '%s' queue in '%s' vhost not found", appConfig.QueueName, vhost))
+		return
+	}
+	params := provider.ProvidedParameters{}
+	for _, param := range ctx.Parameters {
+		switch param {
+		case parameters.QueueLength:
+			params.Set(parameters.QueueLength, metrics.messages)
+		case parameters.Consumers:
+			params.Set(parameters.Consumers, metrics.consumers)
+		case parameters.MessagesReady:
+			params.Set(parameters.MessagesReady, metrics.ready)
+		case parameters.MessagesUnacknowledged:
+			params.Set(parameters.MessagesUnacknowledged, metrics.unacknowledged)
+		}
+	}
+	ctx.PutResult(params)
+	ctx.Finish()
+}
@@ -0,0 +1,30 @@
+package rmqprometheus
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/provider"
+	"time"
+)
+
+type Config struct {
+	Name provider.Name
+	// Url of rabbitmq_prometheus plugin endpoint with scheme (Ex. http://rmq:15692)
+	Url     string
+	Timeout time.Duration
+}
+
+type AppConfig struct {
+	QueueName string `k8s-annotation:"queue"`
+	Vhost     string `k8s-annotation:"vhost"`
+}
+
+type queueKey struct {
+	vhost string
+	queue string
+}
+
+type queueMetrics struct {
+	messages       int
+	ready          int
+	unacknowledged int
+	consumers      int
+}
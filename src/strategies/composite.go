@@ -0,0 +1,211 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
+	"k8s.io/klog"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+const (
+	CompositeStrategiesAnnotation = "composite-strategies"
+	CompositeModeAnnotation       = "composite-mode"
+	CompositeWeightsAnnotation    = "composite-weights"
+)
+
+const (
+	CompositeMax             = "max"
+	CompositeMin             = "min"
+	CompositeWeightedAverage = "weighted-average"
+)
+
+// Composite evaluates strategies listed in app's annotations and combines their results. Parameter of
+// a component strategy can be set for this strategy only with '<strategy>.<parameter>' annotation,
+// otherwise the parameter is shared between components. Modifiers of the component strategies
+// are not applied, composite's modifiers are applied to the combined result instead
+var Composite = strategy.Config{
+	Name:     "composite",
+	YAMLName: "composite",
+	ResultModifiers: []strategy.ResultModifier{
+		modifiers.ScalingMode,
+		modifiers.Stabilization,
+		modifiers.Tolerance,
+		modifiers.WithSteps,
+		modifiers.MinMax,
+		modifiers.PartitionCap,
+		modifiers.ResourceQuota,
+		modifiers.SkipUnstable,
+		modifiers.OverrideLimits,
+		modifiers.Cooldown,
+		modifiers.Behavior,
+	},
+	Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+		return strategy.Result{}, errors.New("composite strategy is not built for the app")
+	},
+	Build: buildComposite,
+}
+
+type compositeComponent struct {
+	strategy strategy.Config
+	// params maps component's parameters to the names of composite's parameters
+	params map[parameter.Name]parameter.Name
+	weight float64
+}
+
+func buildComposite(cfg strategy.Config, ctx strategy.BuildContext) (strategy.Config, error) {
+	names := common.SplitList(ctx.Annotations[ctx.AnnotationsPrefix+CompositeStrategiesAnnotation])
+	if len(names) == 0 {
+		return strategy.Config{}, fmt.Errorf("'%s' annotation doesn't list any strategies", CompositeStrategiesAnnotation)
+	}
+	mode, ok := ctx.Annotations[ctx.AnnotationsPrefix+CompositeModeAnnotation]
+	if !ok {
+		mode = CompositeMax
+	}
+	if mode != CompositeMax && mode != CompositeMin && mode != CompositeWeightedAverage {
+		return strategy.Config{}, fmt.Errorf("unknown composite mode '%s'", mode)
+	}
+	weights, err := parseWeights(ctx.Annotations[ctx.AnnotationsPrefix+CompositeWeightsAnnotation], len(names))
+	if err != nil {
+		return strategy.Config{}, err
+	}
+	required := strategy.RequiredParameters{}
+	components := make([]compositeComponent, 0, len(names))
+
+	for i, name := range names {
+		componentCfg, ok := ctx.Strategies[strategy.YAMLName(name)]
+		if !ok {
+			return strategy.Config{}, fmt.Errorf("'%s' strategy doesn't exist", name)
+		}
+		if componentCfg.Build != nil {
+			return strategy.Config{}, fmt.Errorf("'%s' strategy can't be a part of composite strategy", name)
+		}
+		component := compositeComponent{
+			strategy: componentCfg,
+			params:   map[parameter.Name]parameter.Name{},
+			weight:   weights[i],
+		}
+		for paramName, spec := range componentCfg.RequiredParameters {
+			resolved := parameter.Name(name + "." + string(paramName))
+			if _, ok := ctx.Annotations[ctx.AnnotationsPrefix+string(resolved)]; !ok {
+				resolved = paramName
+			}
+			if existing, ok := required[resolved]; ok && !existing.Type.EqualTo(spec.Type) {
+				return strategy.Config{}, fmt.Errorf(
+					"'%s' parameter is required with different types: %s and %s",
+					resolved, existing.Type.Name, spec.Type.Name,
+				)
+			}
+			required[resolved] = spec
+			component.params[paramName] = resolved
+		}
+		components = append(components, component)
+	}
+	cfg.RequiredParameters = required
+	cfg.Build = nil
+	cfg.Execute = func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+		return executeComposite(components, mode, app, params)
+	}
+	return cfg, nil
+}
+
+func executeComposite(components []compositeComponent, mode string, app scalable.App, params parameter.Values) (strategy.Result, error) {
+	var combined, totalWeight float64
+
+	for i, component := range components {
+		componentParams, err := component.values(params)
+		if err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to prepare '%s' parameters: %w", component.strategy.Name, err)
+		}
+		result, err := component.strategy.Execute(app, componentParams)
+		if err != nil {
+			return strategy.Result{}, fmt.Errorf("'%s' strategy failed: %w", component.strategy.Name, err)
+		}
+		replicas := float64(result.RequiredReplicas)
+		if result.Skip {
+			replicas = float64(app.Replicas)
+		}
+		if klog.V(2) {
+			klog.Infof("%s's replicas required by '%s' strategy: %d", app.Name, component.strategy.Name, int(replicas))
+		}
+		switch {
+		case mode == CompositeWeightedAverage:
+			combined += replicas * component.weight
+			totalWeight += component.weight
+		case i == 0:
+			combined = replicas
+		case mode == CompositeMax:
+			combined = math.Max(combined, replicas)
+		case mode == CompositeMin:
+			combined = math.Min(combined, replicas)
+		}
+	}
+	if mode == CompositeWeightedAverage {
+		combined = combined / totalWeight
+	}
+	reqRepl := int(math.Ceil(combined))
+
+	if reqRepl == app.Replicas {
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number is equal to its current replicas (%d), skipping scaling",
+				app.Name, app.Replicas,
+			)
+		}
+		return strategy.Result{Skip: true}, nil
+	}
+	if klog.V(2) {
+		klog.Infof(
+			"%s's required replicas number will be changed to %d. Parameters: current replicas - %d, mode - %s",
+			app.Name, reqRepl, app.Replicas, mode,
+		)
+	}
+	return strategy.Result{RequiredReplicas: reqRepl}, nil
+}
+
+func (c compositeComponent) values(params parameter.Values) (parameter.Values, error) {
+	values := parameter.EmptyValues()
+	for paramName, resolved := range c.params {
+		spec := c.strategy.RequiredParameters[paramName]
+		m, err := params.MapValueOfType(spec.Type)
+		if err != nil {
+			return parameter.Values{}, err
+		}
+		v := m.MapIndex(reflect.ValueOf(resolved))
+		if !v.IsValid() {
+			return parameter.Values{}, fmt.Errorf("'%s' parameter is not provided", resolved)
+		}
+		if err := values.Insert(paramName, v.Interface(), spec.Type); err != nil {
+			return parameter.Values{}, err
+		}
+	}
+	return values, nil
+}
+
+func parseWeights(annotation string, count int) ([]float64, error) {
+	weights := make([]float64, count)
+	listed := common.SplitList(annotation)
+	if len(listed) == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights, nil
+	}
+	if len(listed) != count {
+		return nil, fmt.Errorf("'%s' annotation must contain %d weights, got %d", CompositeWeightsAnnotation, count, len(listed))
+	}
+	for i, s := range listed {
+		w, err := strconv.ParseFloat(s, 64)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("malformed weight '%s' in '%s' annotation", s, CompositeWeightsAnnotation)
+		}
+		weights[i] = w
+	}
+	return weights, nil
+}
@@ -0,0 +1,135 @@
+package strategies
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// replicasFromParameter creates strategy requiring the number of replicas given by 'value' parameter
+func replicasFromParameter(name string) strategy.Config {
+	return strategy.Config{
+		Name:     strategy.Name(name),
+		YAMLName: strategy.YAMLName(name),
+		RequiredParameters: strategy.RequiredParameters{
+			"value": {Type: parameter.Int},
+		},
+		Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+			if params.Ints["value"] == app.Replicas {
+				return strategy.Result{Skip: true}, nil
+			}
+			return strategy.Result{RequiredReplicas: params.Ints["value"]}, nil
+		},
+	}
+}
+
+func compositeBuildContext(annotations map[string]string) strategy.BuildContext {
+	return strategy.BuildContext{
+		AnnotationsPrefix: "prefix/",
+		Annotations:       annotations,
+		Strategies: map[strategy.YAMLName]strategy.Config{
+			"first":      replicasFromParameter("first"),
+			"second":     replicasFromParameter("second"),
+			"composite":  Composite,
+			"expression": Expression,
+		},
+	}
+}
+
+func TestComposite(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		replicas    int
+		values      map[parameter.Name]int
+		expected    strategy.Result
+	}{
+		{
+			name:        "max by default",
+			annotations: map[string]string{"prefix/composite-strategies": "first, second", "prefix/first.value": ""},
+			replicas:    1,
+			values:      map[parameter.Name]int{"first.value": 3, "value": 5},
+			expected:    strategy.Result{RequiredReplicas: 5},
+		},
+		{
+			name: "min",
+			annotations: map[string]string{
+				"prefix/composite-strategies": "first,second", "prefix/composite-mode": "min", "prefix/first.value": "",
+			},
+			replicas: 1,
+			values:   map[parameter.Name]int{"first.value": 3, "value": 5},
+			expected: strategy.Result{RequiredReplicas: 3},
+		},
+		{
+			name: "weighted average",
+			annotations: map[string]string{
+				"prefix/composite-strategies": "first,second", "prefix/composite-mode": "weighted-average",
+				"prefix/composite-weights": "3,1", "prefix/first.value": "",
+			},
+			replicas: 1,
+			values:   map[parameter.Name]int{"first.value": 2, "value": 7},
+			// (2*3 + 7*1) / 4 = 3.25
+			expected: strategy.Result{RequiredReplicas: 4},
+		},
+		{
+			name: "skipped component keeps current replicas",
+			annotations: map[string]string{
+				"prefix/composite-strategies": "first,second", "prefix/composite-mode": "min", "prefix/first.value": "",
+			},
+			replicas: 4,
+			values:   map[parameter.Name]int{"first.value": 4, "value": 6},
+			expected: strategy.Result{Skip: true},
+		},
+		{
+			name:        "shared parameter",
+			annotations: map[string]string{"prefix/composite-strategies": "first,second"},
+			replicas:    1,
+			values:      map[parameter.Name]int{"value": 2},
+			expected:    strategy.Result{RequiredReplicas: 2},
+		},
+	}
+	for _, tc := range testCases {
+		cfg, err := Composite.Build(Composite, compositeBuildContext(tc.annotations))
+		require.NoError(t, err, tc.name)
+		require.Nil(t, cfg.Build, tc.name)
+
+		params := parameter.EmptyValues()
+		for name, v := range tc.values {
+			require.Contains(t, cfg.RequiredParameters, name, tc.name)
+			params.Ints[name] = v
+		}
+		result, err := cfg.Execute(scalable.App{Name: "app", Replicas: tc.replicas}, params)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestComposite_invalidAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+	}{
+		{name: "no strategies", annotations: map[string]string{"prefix/composite-strategies": " , "}},
+		{name: "unknown strategy", annotations: map[string]string{"prefix/composite-strategies": "first,unknown"}},
+		{name: "built strategy", annotations: map[string]string{"prefix/composite-strategies": "first,expression"}},
+		{name: "nested composite", annotations: map[string]string{"prefix/composite-strategies": "composite"}},
+		{
+			name:        "unknown mode",
+			annotations: map[string]string{"prefix/composite-strategies": "first", "prefix/composite-mode": "sum"},
+		},
+		{
+			name:        "weights count",
+			annotations: map[string]string{"prefix/composite-strategies": "first,second", "prefix/composite-weights": "1"},
+		},
+		{
+			name:        "negative weight",
+			annotations: map[string]string{"prefix/composite-strategies": "first,second", "prefix/composite-weights": "1,-1"},
+		},
+	}
+	for _, tc := range testCases {
+		_, err := Composite.Build(Composite, compositeBuildContext(tc.annotations))
+		require.Error(t, err, tc.name)
+	}
+}
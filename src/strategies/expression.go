@@ -0,0 +1,266 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	"github.com/antonmedv/expr/vm"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/common"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
+	"k8s.io/klog"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ExpressionAnnotation           = "replicas-expression"
+	ExpressionParametersAnnotation = "expression-parameters"
+)
+
+// Expression computes the required number of replicas with the expression from app's annotations.
+// Parameters are available in the expression by their names with dashes replaced by underscores,
+// app's fields are available as 'app' variable. Besides parameters of enabled providers, expression
+// can use parameters declared in annotation as comma separated list of '<name>:<type>' items
+var Expression = strategy.Config{
+	Name:     "expression",
+	YAMLName: "expression",
+	ResultModifiers: []strategy.ResultModifier{
+		modifiers.ScalingMode,
+		modifiers.Stabilization,
+		modifiers.Tolerance,
+		modifiers.WithSteps,
+		modifiers.MinMax,
+		modifiers.PartitionCap,
+		modifiers.ResourceQuota,
+		modifiers.SkipUnstable,
+		modifiers.OverrideLimits,
+		modifiers.Cooldown,
+		modifiers.Behavior,
+	},
+	Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+		return strategy.Result{}, errors.New("expression strategy is not built for the app")
+	},
+	Build: buildExpression,
+}
+
+const expressionAppVariable = "app"
+
+// expressionApp contains app's fields available in expressions
+type expressionApp struct {
+	Name          string
+	Key           string
+	Replicas      int
+	ReadyReplicas int
+	UpdatedDate   time.Time
+}
+
+var expressionFunctions = map[string]interface{}{
+	"float": func(x interface{}) float64 { return toFloat(x) },
+	"ceil":  func(x interface{}) float64 { return math.Ceil(toFloat(x)) },
+	"floor": func(x interface{}) float64 { return math.Floor(toFloat(x)) },
+	"round": func(x interface{}) float64 { return math.Round(toFloat(x)) },
+	"max":   func(x, y interface{}) float64 { return math.Max(toFloat(x), toFloat(y)) },
+	"min":   func(x, y interface{}) float64 { return math.Min(toFloat(x), toFloat(y)) },
+}
+
+type compiledExpression struct {
+	program   *vm.Program
+	variables map[string]parameter.Name
+	required  strategy.RequiredParameters
+}
+
+// compiledExpressionsSize bounds the number of cached programs, so edits of annotations don't grow the cache
+const compiledExpressionsSize = 1024
+
+// compiledExpressions caches programs between executor rounds, they are keyed
+// by the expression and the types of parameters available for it. lru.New fails only for non-positive sizes
+var compiledExpressions, _ = lru.New(compiledExpressionsSize)
+
+func buildExpression(cfg strategy.Config, ctx strategy.BuildContext) (strategy.Config, error) {
+	code, ok := ctx.Annotations[ctx.AnnotationsPrefix+ExpressionAnnotation]
+	if !ok {
+		return strategy.Config{}, fmt.Errorf("'%s' annotation is not specified", ExpressionAnnotation)
+	}
+	available := map[parameter.Name]parameter.Type{}
+	for name, paramType := range ctx.Parameters {
+		available[name] = paramType
+	}
+	declared, err := parseDeclaredParameters(ctx.Annotations[ctx.AnnotationsPrefix+ExpressionParametersAnnotation])
+	if err != nil {
+		return strategy.Config{}, err
+	}
+	for name, paramType := range declared {
+		available[name] = paramType
+	}
+	compiled, err := compileExpression(code, available)
+	if err != nil {
+		return strategy.Config{}, err
+	}
+	cfg.RequiredParameters = compiled.required
+	cfg.Build = nil
+	cfg.Execute = func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+		return compiled.execute(app, params)
+	}
+	return cfg, nil
+}
+
+func compileExpression(code string, available map[parameter.Name]parameter.Type) (compiledExpression, error) {
+	signature := make([]string, 0, len(available))
+	for name, paramType := range available {
+		signature = append(signature, string(name)+":"+paramType.Name)
+	}
+	sort.Strings(signature)
+	cacheKey := code + "\x00" + strings.Join(signature, ",")
+
+	if cached, ok := compiledExpressions.Get(cacheKey); ok {
+		return cached.(compiledExpression), nil
+	}
+	env := map[string]interface{}{expressionAppVariable: expressionApp{}}
+	for name, fn := range expressionFunctions {
+		env[name] = fn
+	}
+	variables := map[string]parameter.Name{}
+	for name, paramType := range available {
+		variable := expressionVariable(name)
+		if _, reserved := env[variable]; reserved {
+			continue
+		}
+		env[variable] = reflect.Zero(paramType.ReflectType).Interface()
+		variables[variable] = name
+	}
+	program, err := expr.Compile(code, expr.Env(env), expr.AsFloat64())
+	if err != nil {
+		return compiledExpression{}, fmt.Errorf("failed to compile expression: %w", err)
+	}
+	tree, err := parser.Parse(code)
+	if err != nil {
+		return compiledExpression{}, fmt.Errorf("failed to parse expression: %w", err)
+	}
+	collector := identifiersCollector{}
+	ast.Walk(&tree.Node, collector)
+
+	compiled := compiledExpression{
+		program:   program,
+		variables: map[string]parameter.Name{},
+		required:  strategy.RequiredParameters{},
+	}
+	for variable := range collector {
+		name, ok := variables[variable]
+		if !ok {
+			continue
+		}
+		compiled.variables[variable] = name
+		compiled.required[name] = strategy.ParameterSpec{Type: available[name]}
+	}
+	compiledExpressions.Add(cacheKey, compiled)
+	return compiled, nil
+}
+
+func (c compiledExpression) execute(app scalable.App, params parameter.Values) (strategy.Result, error) {
+	env := map[string]interface{}{
+		expressionAppVariable: expressionApp{
+			Name:          app.Name,
+			Key:           app.Key,
+			Replicas:      app.Replicas,
+			ReadyReplicas: app.ReadyReplicas,
+			UpdatedDate:   app.UpdatedDate,
+		},
+	}
+	for name, fn := range expressionFunctions {
+		env[name] = fn
+	}
+	for variable, name := range c.variables {
+		m, err := params.MapValueOfType(c.required[name].Type)
+		if err != nil {
+			return strategy.Result{}, err
+		}
+		v := m.MapIndex(reflect.ValueOf(name))
+		if !v.IsValid() {
+			return strategy.Result{}, fmt.Errorf("'%s' parameter is not provided", name)
+		}
+		env[variable] = v.Interface()
+	}
+	out, err := expr.Run(c.program, env)
+	if err != nil {
+		return strategy.Result{}, fmt.Errorf("failed to evaluate expression: %w", err)
+	}
+	value := toFloat(out)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strategy.Result{}, fmt.Errorf("expression evaluated to %v", value)
+	}
+	reqRepl := int(math.Ceil(value))
+
+	if reqRepl == app.Replicas {
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number is equal to its current replicas (%d), skipping scaling",
+				app.Name, app.Replicas,
+			)
+		}
+		return strategy.Result{Skip: true}, nil
+	}
+	if klog.V(2) {
+		klog.Infof(
+			"%s's required replicas number will be changed to %d. Parameters: current replicas - %d, expression value - %.2f",
+			app.Name, reqRepl, app.Replicas, value,
+		)
+	}
+	return strategy.Result{RequiredReplicas: reqRepl}, nil
+}
+
+func parseDeclaredParameters(annotation string) (map[parameter.Name]parameter.Type, error) {
+	declared := map[parameter.Name]parameter.Type{}
+	for _, item := range common.SplitList(annotation) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed parameter declaration '%s', expected '<name>:<type>'", item)
+		}
+		paramType, ok := parameter.TypeByName(strings.TrimSpace(parts[1]))
+		if !ok {
+			return nil, fmt.Errorf("unknown type '%s' of '%s' parameter", parts[1], parts[0])
+		}
+		declared[parameter.Name(strings.TrimSpace(parts[0]))] = paramType
+	}
+	return declared, nil
+}
+
+func expressionVariable(name parameter.Name) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(string(name))
+}
+
+type identifiersCollector map[string]struct{}
+
+func (c identifiersCollector) Enter(_ *ast.Node) {}
+
+func (c identifiersCollector) Exit(node *ast.Node) {
+	if identifier, ok := (*node).(*ast.IdentifierNode); ok {
+		c[identifier.Value] = struct{}{}
+	}
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case time.Duration:
+		return n.Seconds()
+	}
+	return math.NaN()
+}
@@ -0,0 +1,155 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func expressionBuildContext(annotations map[string]string) strategy.BuildContext {
+	return strategy.BuildContext{
+		AnnotationsPrefix: "prefix/",
+		Annotations:       annotations,
+		Parameters: map[parameter.Name]parameter.Type{
+			parameters.QueueLength:    parameter.Int,
+			parameters.PublishRate:    parameter.Float,
+			parameters.HeadMessageAge: parameter.Duration,
+		},
+	}
+}
+
+func TestExpression(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		declared   string
+		replicas   int
+		required   []parameter.Name
+		expected   strategy.Result
+	}{
+		{
+			name:       "provided parameters",
+			expression: "queue_length / 10 + publish_rate",
+			replicas:   1,
+			required:   []parameter.Name{parameters.QueueLength, parameters.PublishRate},
+			// 25 / 10 + 1.5 = 4 as integer division is used for ints
+			expected: strategy.Result{RequiredReplicas: 4},
+		},
+		{
+			name:       "fractional value is rounded up",
+			expression: "float(queue_length) / 10",
+			replicas:   1,
+			required:   []parameter.Name{parameters.QueueLength},
+			expected:   strategy.Result{RequiredReplicas: 3},
+		},
+		{
+			name:       "duration in seconds and functions",
+			expression: "max(float(head_message_age) / 30, app.Replicas)",
+			replicas:   1,
+			required:   []parameter.Name{parameters.HeadMessageAge},
+			expected:   strategy.Result{RequiredReplicas: 4},
+		},
+		{
+			name:       "declared parameter",
+			expression: "custom_workers * 2",
+			declared:   "custom-workers:int",
+			replicas:   1,
+			required:   []parameter.Name{"custom-workers"},
+			expected:   strategy.Result{RequiredReplicas: 6},
+		},
+		{
+			name:       "equal to current replicas",
+			expression: "app.ReadyReplicas",
+			replicas:   2,
+			expected:   strategy.Result{Skip: true},
+		},
+	}
+	for _, tc := range testCases {
+		annotations := map[string]string{"prefix/replicas-expression": tc.expression}
+		if tc.declared != "" {
+			annotations["prefix/expression-parameters"] = tc.declared
+		}
+		cfg, err := Expression.Build(Expression, expressionBuildContext(annotations))
+		require.NoError(t, err, tc.name)
+		require.Len(t, cfg.RequiredParameters, len(tc.required), tc.name)
+		for _, name := range tc.required {
+			require.Contains(t, cfg.RequiredParameters, name, tc.name)
+		}
+		params := parameter.EmptyValues()
+		params.Ints[parameters.QueueLength] = 25
+		params.Floats[parameters.PublishRate] = 1.5
+		params.Durations[parameters.HeadMessageAge] = 2 * time.Minute
+		params.Ints["custom-workers"] = 3
+
+		result, err := cfg.Execute(scalable.App{Name: "app", Replicas: tc.replicas, ReadyReplicas: 2}, params)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestExpression_errors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		buildError  bool
+	}{
+		{name: "no expression", annotations: map[string]string{}, buildError: true},
+		{name: "unknown variable", annotations: map[string]string{"prefix/replicas-expression": "unknown * 2"}, buildError: true},
+		{
+			name: "malformed declaration",
+			annotations: map[string]string{
+				"prefix/replicas-expression": "1", "prefix/expression-parameters": "custom",
+			},
+			buildError: true,
+		},
+		{
+			name: "unknown declared type",
+			annotations: map[string]string{
+				"prefix/replicas-expression": "1", "prefix/expression-parameters": "custom:complex",
+			},
+			buildError: true,
+		},
+		{name: "infinite value", annotations: map[string]string{"prefix/replicas-expression": "1 / float(queue_length)"}},
+		{
+			name: "parameter isn't provided",
+			annotations: map[string]string{
+				"prefix/replicas-expression": "custom", "prefix/expression-parameters": "custom:int",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		cfg, err := Expression.Build(Expression, expressionBuildContext(tc.annotations))
+		if tc.buildError {
+			require.Error(t, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+
+		params := parameter.EmptyValues()
+		params.Ints[parameters.QueueLength] = 0
+		_, err = cfg.Execute(scalable.App{Name: "app"}, params)
+		require.Error(t, err, tc.name)
+	}
+}
+
+func TestExpression_cacheIsBounded(t *testing.T) {
+	for i := 0; i <= compiledExpressionsSize; i++ {
+		annotations := map[string]string{"prefix/replicas-expression": fmt.Sprintf("queue_length + %d", i)}
+		_, err := Expression.Build(Expression, expressionBuildContext(annotations))
+		require.NoError(t, err)
+	}
+	require.Equal(t, compiledExpressionsSize, compiledExpressions.Len())
+}
+
+func TestParseDeclaredParameters(t *testing.T) {
+	declared, err := parseDeclaredParameters(" first:int, second : duration ,")
+	require.NoError(t, err)
+	require.Len(t, declared, 2)
+	require.True(t, declared["first"].EqualTo(parameter.Int))
+	require.True(t, declared["second"].EqualTo(parameter.Duration))
+}
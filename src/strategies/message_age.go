@@ -0,0 +1,90 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
+	"k8s.io/klog"
+	"math"
+)
+
+// OldestMessageAge scales app proportionally to the ratio between the age of the queue's head message and
+// the target age. Scale down is performed one replica at a time while the ratio stays below the lower threshold.
+var OldestMessageAge = strategy.Config{
+	Name:     "oldest-message-age",
+	YAMLName: "oldest-message-age",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.HeadMessageAge:               {Type: parameter.Duration},
+		parameters.TargetMessageAge:             {Type: parameter.Duration},
+		parameters.MessageAgeScaleUpThreshold:   {Type: parameter.Float, DefaultValue: 1.},
+		parameters.MessageAgeScaleDownThreshold: {Type: parameter.Float, DefaultValue: 0.5},
+	},
+	ResultModifiers: []strategy.ResultModifier{
+		modifiers.ScalingMode,
+		modifiers.Stabilization,
+		modifiers.Tolerance,
+		modifiers.WithSteps,
+		modifiers.MinMax,
+		modifiers.PartitionCap,
+		modifiers.ResourceQuota,
+		modifiers.SkipUnstable,
+		modifiers.OverrideLimits,
+		modifiers.Cooldown,
+		modifiers.Behavior,
+	},
+	Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+		age, target := params.Durations[parameters.HeadMessageAge], params.Durations[parameters.TargetMessageAge]
+		upThreshold := params.Floats[parameters.MessageAgeScaleUpThreshold]
+		downThreshold := params.Floats[parameters.MessageAgeScaleDownThreshold]
+
+		if target <= 0 {
+			return strategy.Result{}, fmt.Errorf("'%s' must be positive, got %s", parameters.TargetMessageAge, target)
+		}
+		if downThreshold >= upThreshold {
+			return strategy.Result{}, fmt.Errorf(
+				"'%s' (%.2f) must be below '%s' (%.2f)",
+				parameters.MessageAgeScaleDownThreshold, downThreshold, parameters.MessageAgeScaleUpThreshold, upThreshold,
+			)
+		}
+		ratio := float64(age) / float64(target)
+
+		var reqRepl int
+
+		switch {
+		case ratio > upThreshold:
+			reqRepl = int(math.Ceil(float64(maxInt(app.Replicas, 1)) * ratio))
+			if reqRepl <= app.Replicas {
+				reqRepl = app.Replicas + 1
+			}
+		case ratio < downThreshold && app.Replicas > 0:
+			reqRepl = app.Replicas - 1
+		default:
+			if klog.V(2) {
+				klog.Infof(
+					"%s's head message age (%s) is within thresholds of target age (%s), skipping scaling",
+					app.Name, age, target,
+				)
+			}
+			return strategy.Result{Skip: true}, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number will be changed to %d. "+
+					"Parameters: current replicas - %d, head message age - %s, target age - %s, "+
+					"scale up threshold - %.2f, scale down threshold - %.2f",
+				app.Name, reqRepl, app.Replicas, age, target, upThreshold, downThreshold,
+			)
+		}
+		return strategy.Result{RequiredReplicas: reqRepl}, nil
+	},
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,104 @@
+package strategies
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestOldestMessageAge(t *testing.T) {
+	testCases := []struct {
+		name     string
+		replicas int
+		age      time.Duration
+		target   time.Duration
+		expected strategy.Result
+	}{
+		{
+			name:     "scale up proportionally",
+			replicas: 2,
+			age:      3 * time.Minute,
+			target:   time.Minute,
+			expected: strategy.Result{RequiredReplicas: 6},
+		},
+		{
+			name:     "scale up from zero",
+			replicas: 0,
+			age:      3 * time.Minute,
+			target:   time.Minute,
+			expected: strategy.Result{RequiredReplicas: 3},
+		},
+		{
+			name:     "scale up at least by one",
+			replicas: 10,
+			age:      61 * time.Second,
+			target:   time.Minute,
+			expected: strategy.Result{RequiredReplicas: 11},
+		},
+		{
+			name:     "scale down by one",
+			replicas: 4,
+			age:      10 * time.Second,
+			target:   time.Minute,
+			expected: strategy.Result{RequiredReplicas: 3},
+		},
+		{
+			name:     "within thresholds",
+			replicas: 4,
+			age:      45 * time.Second,
+			target:   time.Minute,
+			expected: strategy.Result{Skip: true},
+		},
+		{
+			name:     "no replicas to remove",
+			replicas: 0,
+			age:      0,
+			target:   time.Minute,
+			expected: strategy.Result{Skip: true},
+		},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Durations[parameters.HeadMessageAge] = tc.age
+		params.Durations[parameters.TargetMessageAge] = tc.target
+		params.Floats[parameters.MessageAgeScaleUpThreshold] = 1
+		params.Floats[parameters.MessageAgeScaleDownThreshold] = 0.5
+
+		result, err := OldestMessageAge.Execute(scalable.App{Name: "app", Replicas: tc.replicas}, params)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestOldestMessageAge_invalidTarget(t *testing.T) {
+	params := parameter.EmptyValues()
+	params.Durations[parameters.TargetMessageAge] = 0
+
+	_, err := OldestMessageAge.Execute(scalable.App{Name: "app"}, params)
+	require.Error(t, err)
+}
+
+func TestOldestMessageAge_invalidThresholds(t *testing.T) {
+	testCases := []struct {
+		name string
+		up   float64
+		down float64
+	}{
+		{name: "equal thresholds", up: 1, down: 1},
+		{name: "scale down threshold above scale up one", up: 1, down: 1.5},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Durations[parameters.HeadMessageAge] = time.Minute
+		params.Durations[parameters.TargetMessageAge] = time.Minute
+		params.Floats[parameters.MessageAgeScaleUpThreshold] = tc.up
+		params.Floats[parameters.MessageAgeScaleDownThreshold] = tc.down
+
+		_, err := OldestMessageAge.Execute(scalable.App{Name: "app", Replicas: 2}, params)
+		require.Error(t, err, tc.name)
+	}
+}
@@ -0,0 +1,242 @@
+package modifiers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/klog"
+	"math"
+	"sigs.k8s.io/yaml"
+	"time"
+)
+
+const scaleEventsStateName = "scale-events"
+
+type scaleEvent struct {
+	Time   time.Time
+	Change int
+}
+
+// scaleEvents keeps app's changes of replicas number made within the longest period of its policies
+type scaleEvents struct {
+	Period time.Duration
+	Events []scaleEvent
+}
+
+// Behavior limits the rate of scaling with policies having semantics of HPA's scaling behavior. Policies are
+// specified in YAML or JSON in the same format as HPA's 'behavior' field, stabilization windows are not
+// supported by this modifier (see Stabilization). Like HPA, missing 'scaleUp' or 'scaleDown' policies are
+// replaced with HPA's default ones. Scale events are recorded with RecordScaleEvent once replicas of the
+// app are actually changed
+var Behavior = strategy.ResultModifier{
+	Name: "scaling-behavior",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.ScalingBehavior: {Type: parameter.String, DefaultValue: ""},
+	},
+	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+		spec := params.Strings[parameters.ScalingBehavior]
+
+		if prev.Skip || len(spec) == 0 {
+			return prev, nil
+		}
+		var behavior autoscalingv2.HorizontalPodAutoscalerBehavior
+		if err := yaml.UnmarshalStrict([]byte(spec), &behavior); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to parse scaling behavior: %w", err)
+		}
+		behavior.ScaleUp = withDefaultPolicies(behavior.ScaleUp, defaultScaleUpPolicies)
+		behavior.ScaleDown = withDefaultPolicies(behavior.ScaleDown, defaultScaleDownPolicies)
+		now := time.Now()
+
+		var recorded scaleEvents
+		if _, err := app.State.Get(scaleEventsStateName, &recorded); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to load scale events: %w", err)
+		}
+		period := longestPeriod(behavior)
+		events := keepEventsWithin(recorded.Events, now, period)
+
+		if err := app.State.Set(scaleEventsStateName, scaleEvents{Period: period, Events: events}); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to save scale events: %w", err)
+		}
+
+		required := prev.RequiredReplicas
+		switch {
+		case required > app.Replicas:
+			if limit := scaleUpLimit(app.Replicas, events, now, behavior.ScaleUp); required > limit {
+				required = limit
+			}
+		case required < app.Replicas:
+			if limit := scaleDownLimit(app.Replicas, events, now, behavior.ScaleDown); required < limit {
+				required = limit
+			}
+		}
+		if required == prev.RequiredReplicas {
+			return prev, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number (%d) is limited to %d by scaling behavior policies",
+				app.Name, prev.RequiredReplicas, required,
+			)
+		}
+		if required == app.Replicas {
+			return strategy.Result{Skip: true, SkipReason: "scale is limited by scaling behavior policies"}, nil
+		}
+		prev.RequiredReplicas = required
+		return prev, nil
+	},
+}
+
+// RecordScaleEvent records the change of app's replicas number for apps limited by scaling behavior policies
+func RecordScaleEvent(app scalable.App, change int) error {
+	var recorded scaleEvents
+	found, err := app.State.Get(scaleEventsStateName, &recorded)
+	if errors.Is(err, state.ErrNotConfigured) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load scale events: %w", err)
+	}
+	if !found || change == 0 {
+		return nil
+	}
+	now := time.Now()
+	recorded.Events = append(keepEventsWithin(recorded.Events, now, recorded.Period), scaleEvent{Time: now, Change: change})
+
+	if err := app.State.Set(scaleEventsStateName, recorded); err != nil {
+		return fmt.Errorf("failed to save scale events: %w", err)
+	}
+	return nil
+}
+
+func scaleUpLimit(current int, events []scaleEvent, now time.Time, rules *autoscalingv2.HPAScalingRules) int {
+	selectPolicy := selectPolicyOf(rules)
+	if selectPolicy == autoscalingv2.DisabledPolicySelect {
+		return current
+	}
+	if len(rules.Policies) == 0 {
+		return math.MaxInt32
+	}
+	limit := math.MaxInt32
+	if selectPolicy == autoscalingv2.MaxPolicySelect {
+		limit = math.MinInt32
+	}
+	for _, policy := range rules.Policies {
+		periodStart := current - sumChanges(events, now, policy.PeriodSeconds, 1)
+
+		var policyLimit int
+		switch policy.Type {
+		case autoscalingv2.PodsScalingPolicy:
+			policyLimit = periodStart + int(policy.Value)
+		case autoscalingv2.PercentScalingPolicy:
+			policyLimit = int(math.Ceil(float64(periodStart) * (1 + float64(policy.Value)/100)))
+		}
+		if (selectPolicy == autoscalingv2.MaxPolicySelect) == (policyLimit > limit) {
+			limit = policyLimit
+		}
+	}
+	if limit < current {
+		return current
+	}
+	return limit
+}
+
+func scaleDownLimit(current int, events []scaleEvent, now time.Time, rules *autoscalingv2.HPAScalingRules) int {
+	selectPolicy := selectPolicyOf(rules)
+	if selectPolicy == autoscalingv2.DisabledPolicySelect {
+		return current
+	}
+	if len(rules.Policies) == 0 {
+		return math.MinInt32
+	}
+	limit := math.MinInt32
+	if selectPolicy == autoscalingv2.MaxPolicySelect {
+		limit = math.MaxInt32
+	}
+	for _, policy := range rules.Policies {
+		periodStart := current - sumChanges(events, now, policy.PeriodSeconds, -1)
+
+		var policyLimit int
+		switch policy.Type {
+		case autoscalingv2.PodsScalingPolicy:
+			policyLimit = periodStart - int(policy.Value)
+		case autoscalingv2.PercentScalingPolicy:
+			// HPA truncates the number of replicas allowed to be removed by percent policies
+			policyLimit = int(float64(periodStart) * (1 - float64(policy.Value)/100))
+		}
+		// Policy allowing the highest change has the lowest limit when scaling down
+		if (selectPolicy == autoscalingv2.MaxPolicySelect) == (policyLimit < limit) {
+			limit = policyLimit
+		}
+	}
+	if limit > current {
+		return current
+	}
+	return limit
+}
+
+// defaultScaleUpPolicies and defaultScaleDownPolicies are policies HPA uses when none are specified
+var (
+	defaultScaleUpPolicies = []autoscalingv2.HPAScalingPolicy{
+		{Type: autoscalingv2.PodsScalingPolicy, Value: 4, PeriodSeconds: 15},
+		{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+	}
+	defaultScaleDownPolicies = []autoscalingv2.HPAScalingPolicy{
+		{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+	}
+)
+
+func withDefaultPolicies(rules *autoscalingv2.HPAScalingRules, policies []autoscalingv2.HPAScalingPolicy) *autoscalingv2.HPAScalingRules {
+	if rules == nil {
+		rules = &autoscalingv2.HPAScalingRules{}
+	}
+	if len(rules.Policies) == 0 {
+		rules.Policies = policies
+	}
+	return rules
+}
+
+func selectPolicyOf(rules *autoscalingv2.HPAScalingRules) autoscalingv2.ScalingPolicySelect {
+	if rules.SelectPolicy == nil {
+		return autoscalingv2.MaxPolicySelect
+	}
+	return *rules.SelectPolicy
+}
+
+// sumChanges returns the sum of changes made in the given direction during the period
+func sumChanges(events []scaleEvent, now time.Time, periodSeconds int32, direction int) int {
+	period := time.Duration(periodSeconds) * time.Second
+	sum := 0
+	for _, event := range events {
+		if now.Sub(event.Time) <= period && event.Change*direction > 0 {
+			sum += event.Change
+		}
+	}
+	return sum
+}
+
+func longestPeriod(behavior autoscalingv2.HorizontalPodAutoscalerBehavior) time.Duration {
+	var longest int32
+	for _, rules := range []*autoscalingv2.HPAScalingRules{behavior.ScaleUp, behavior.ScaleDown} {
+		for _, policy := range rules.Policies {
+			if policy.PeriodSeconds > longest {
+				longest = policy.PeriodSeconds
+			}
+		}
+	}
+	return time.Duration(longest) * time.Second
+}
+
+func keepEventsWithin(events []scaleEvent, now time.Time, window time.Duration) []scaleEvent {
+	kept := make([]scaleEvent, 0, len(events))
+	for _, event := range events {
+		if now.Sub(event.Time) <= window {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
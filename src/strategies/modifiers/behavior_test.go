@@ -0,0 +1,189 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	"math"
+	"testing"
+	"time"
+)
+
+func scalingRules(selectPolicy autoscalingv2.ScalingPolicySelect, policies ...autoscalingv2.HPAScalingPolicy) *autoscalingv2.HPAScalingRules {
+	rules := &autoscalingv2.HPAScalingRules{Policies: policies}
+	if len(selectPolicy) > 0 {
+		rules.SelectPolicy = &selectPolicy
+	}
+	return rules
+}
+
+func TestScaleUpLimit(t *testing.T) {
+	pods := autoscalingv2.HPAScalingPolicy{Type: autoscalingv2.PodsScalingPolicy, Value: 4, PeriodSeconds: 60}
+	percent := autoscalingv2.HPAScalingPolicy{Type: autoscalingv2.PercentScalingPolicy, Value: 50, PeriodSeconds: 60}
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		current  int
+		events   []scaleEvent
+		rules    *autoscalingv2.HPAScalingRules
+		expected int
+	}{
+		{name: "pods", current: 10, rules: scalingRules("", pods), expected: 14},
+		{name: "percent", current: 10, rules: scalingRules("", percent), expected: 15},
+		{name: "percent is rounded up", current: 3, rules: scalingRules("", percent), expected: 5},
+		{name: "max policy by default", current: 10, rules: scalingRules("", pods, percent), expected: 15},
+		{name: "min policy", current: 10, rules: scalingRules(autoscalingv2.MinPolicySelect, pods, percent), expected: 14},
+		{name: "disabled", current: 10, rules: scalingRules(autoscalingv2.DisabledPolicySelect, pods), expected: 10},
+		{name: "no policies", current: 10, rules: scalingRules(""), expected: math.MaxInt32},
+		{
+			name:    "changes within period",
+			current: 12,
+			events: []scaleEvent{
+				{Time: now.Add(-30 * time.Second), Change: 2},
+				{Time: now.Add(-20 * time.Second), Change: -1},
+			},
+			rules: scalingRules("", pods),
+			// the period started with 10 replicas, scale downs are not taken into account
+			expected: 14,
+		},
+		{
+			name:     "changes outside of period",
+			current:  12,
+			events:   []scaleEvent{{Time: now.Add(-2 * time.Minute), Change: 2}},
+			rules:    scalingRules("", pods),
+			expected: 16,
+		},
+		{
+			name:     "limit is exhausted",
+			current:  14,
+			events:   []scaleEvent{{Time: now.Add(-30 * time.Second), Change: 6}},
+			rules:    scalingRules("", pods),
+			expected: 14,
+		},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, scaleUpLimit(tc.current, tc.events, now, tc.rules), tc.name)
+	}
+}
+
+func TestScaleDownLimit(t *testing.T) {
+	pods := autoscalingv2.HPAScalingPolicy{Type: autoscalingv2.PodsScalingPolicy, Value: 2, PeriodSeconds: 60}
+	percent := autoscalingv2.HPAScalingPolicy{Type: autoscalingv2.PercentScalingPolicy, Value: 50, PeriodSeconds: 60}
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		current  int
+		events   []scaleEvent
+		rules    *autoscalingv2.HPAScalingRules
+		expected int
+	}{
+		{name: "pods", current: 10, rules: scalingRules("", pods), expected: 8},
+		{name: "percent", current: 10, rules: scalingRules("", percent), expected: 5},
+		{name: "percent is truncated", current: 5, rules: scalingRules("", percent), expected: 2},
+		{name: "max policy by default", current: 10, rules: scalingRules("", pods, percent), expected: 5},
+		{name: "min policy", current: 10, rules: scalingRules(autoscalingv2.MinPolicySelect, pods, percent), expected: 8},
+		{name: "disabled", current: 10, rules: scalingRules(autoscalingv2.DisabledPolicySelect, pods), expected: 10},
+		{name: "no policies", current: 10, rules: scalingRules(""), expected: math.MinInt32},
+		{
+			name:     "changes within period",
+			current:  9,
+			events:   []scaleEvent{{Time: now.Add(-30 * time.Second), Change: -1}},
+			rules:    scalingRules("", pods),
+			expected: 8,
+		},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, scaleDownLimit(tc.current, tc.events, now, tc.rules), tc.name)
+	}
+}
+
+func TestBehavior(t *testing.T) {
+	appState := state.For(state.NewMemoryStore(), "ns/app")
+	app := scalable.App{Key: "ns/app", Name: "app", Replicas: 10, State: appState}
+
+	params := parameter.EmptyValues()
+	params.Strings[parameters.ScalingBehavior] = `
+scaleUp:
+  policies:
+  - type: Pods
+    value: 4
+    periodSeconds: 60
+`
+	result, err := Behavior.Execute(app, params, strategy.Result{RequiredReplicas: 20})
+	require.NoError(t, err)
+	require.Equal(t, strategy.Result{RequiredReplicas: 14}, result)
+
+	// Limit isn't changed until the scaling is recorded
+	result, err = Behavior.Execute(app, params, strategy.Result{RequiredReplicas: 20})
+	require.NoError(t, err)
+	require.Equal(t, strategy.Result{RequiredReplicas: 14}, result)
+
+	require.NoError(t, RecordScaleEvent(app, 3))
+	app.Replicas = 13
+	result, err = Behavior.Execute(app, params, strategy.Result{RequiredReplicas: 20})
+	require.NoError(t, err)
+	require.Equal(t, strategy.Result{RequiredReplicas: 14}, result)
+
+	result, err = Behavior.Execute(app, params, strategy.Result{RequiredReplicas: 8})
+	require.NoError(t, err)
+	require.Equal(t, strategy.Result{RequiredReplicas: 8}, result, "Expected scale down not to be limited")
+}
+
+func TestBehavior_defaultPolicies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		behavior string
+		replicas int
+		required int
+		expected int
+	}{
+		{
+			name:     "default scale up policies",
+			behavior: "scaleDown: {selectPolicy: Disabled}",
+			replicas: 10,
+			required: 30,
+			expected: 20,
+		},
+		{
+			name:     "default scale up policies with few replicas",
+			behavior: "scaleDown: {selectPolicy: Disabled}",
+			replicas: 2,
+			required: 10,
+			expected: 6,
+		},
+		{
+			name:     "default scale down policies",
+			behavior: "scaleUp: {selectPolicy: Disabled}",
+			replicas: 10,
+			required: 1,
+			expected: 1,
+		},
+	}
+	for _, tc := range testCases {
+		app := scalable.App{Key: "ns/app", Name: "app", Replicas: tc.replicas, State: state.For(state.NewMemoryStore(), "ns/app")}
+		params := parameter.EmptyValues()
+		params.Strings[parameters.ScalingBehavior] = tc.behavior
+
+		result, err := Behavior.Execute(app, params, strategy.Result{RequiredReplicas: tc.required})
+		require.NoError(t, err, tc.name)
+		require.Equal(t, strategy.Result{RequiredReplicas: tc.expected}, result, tc.name)
+	}
+}
+
+func TestRecordScaleEvent_withoutBehavior(t *testing.T) {
+	appState := state.For(state.NewMemoryStore(), "ns/app")
+	app := scalable.App{Key: "ns/app", State: appState}
+	require.NoError(t, RecordScaleEvent(app, 2))
+
+	found, err := appState.Get(scaleEventsStateName, &scaleEvents{})
+	require.NoError(t, err)
+	require.False(t, found, "Expected events not to be recorded for apps without scaling behavior")
+
+	require.NoError(t, RecordScaleEvent(scalable.App{Key: "ns/app"}, 2), "Expected apps without state to be ignored")
+}
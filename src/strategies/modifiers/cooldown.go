@@ -23,6 +23,6 @@ var Cooldown = strategy.ResultModifier{
 		if klog.V(2) {
 			klog.Infof("%s is cooled down, waiting more (date %s, duration %s)", app.Name, app.UpdatedDate, delay)
 		}
-		return strategy.Result{Skip: true}, nil
+		return strategy.Result{Skip: true, SkipReason: "cooldown delay has not passed"}, nil
 	},
 }
@@ -28,7 +28,8 @@ var MinMax = strategy.ResultModifier{
 					app.Name, replicas, max,
 				)
 			}
-			return strategy.Result{RequiredReplicas: max}, nil
+			prev.RequiredReplicas = max
+			return prev, nil
 		case replicas < min:
 			if klog.V(2) {
 				klog.Infof(
@@ -36,7 +37,8 @@ var MinMax = strategy.ResultModifier{
 					app.Name, replicas, min,
 				)
 			}
-			return strategy.Result{RequiredReplicas: min}, nil
+			prev.RequiredReplicas = min
+			return prev, nil
 		}
 		return prev, nil
 	},
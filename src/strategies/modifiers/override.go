@@ -27,7 +27,7 @@ var OverrideLimits = strategy.ResultModifier{
 			if klog.V(2) {
 				klog.Infof("%s limits are override, do nothing", app.Key)
 			}
-			return strategy.Result{Skip: true}, nil
+			return strategy.Result{Skip: true, SkipReason: "replicas number is manually set beyond limits"}, nil
 		}
 		return prev, nil
 	},
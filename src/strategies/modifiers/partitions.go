@@ -0,0 +1,33 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"k8s.io/klog"
+)
+
+// PartitionCap limits the number of replicas by the number of partitions, consumers exceeding it would be idle.
+// Zero number of partitions disables the limit
+var PartitionCap = strategy.ResultModifier{
+	Name: "partition-cap",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.Partitions: {Type: parameter.Int, DefaultValue: 0},
+	},
+	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+		partitions := params.Ints[parameters.Partitions]
+
+		if prev.Skip || partitions <= 0 || prev.RequiredReplicas <= partitions {
+			return prev, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number (%d) exceeds the number of partitions (%d)",
+				app.Name, prev.RequiredReplicas, partitions,
+			)
+		}
+		prev.RequiredReplicas = partitions
+		return prev, nil
+	},
+}
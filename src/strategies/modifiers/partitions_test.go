@@ -0,0 +1,39 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPartitionCap(t *testing.T) {
+	testCases := []struct {
+		name       string
+		partitions int
+		prev       strategy.Result
+		expected   strategy.Result
+	}{
+		{name: "no partitions", partitions: 0, prev: strategy.Result{RequiredReplicas: 30}, expected: strategy.Result{RequiredReplicas: 30}},
+		{name: "below partitions", partitions: 12, prev: strategy.Result{RequiredReplicas: 5}, expected: strategy.Result{RequiredReplicas: 5}},
+		{name: "equal to partitions", partitions: 12, prev: strategy.Result{RequiredReplicas: 12}, expected: strategy.Result{RequiredReplicas: 12}},
+		{name: "above partitions", partitions: 12, prev: strategy.Result{RequiredReplicas: 30}, expected: strategy.Result{RequiredReplicas: 12}},
+		{
+			name:       "skipped",
+			partitions: 12,
+			prev:       strategy.Result{Skip: true, SkipReason: "cooldown"},
+			expected:   strategy.Result{Skip: true, SkipReason: "cooldown"},
+		},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Ints[parameters.Partitions] = tc.partitions
+
+		app := scalable.App{Name: "app", Replicas: 10}
+		result, err := PartitionCap.Execute(app, params, tc.prev)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
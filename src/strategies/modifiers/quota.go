@@ -0,0 +1,41 @@
+package modifiers
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"k8s.io/klog"
+)
+
+// ResourceQuota limits scaling up by the number of replicas that fit into namespace's resource quotas
+var ResourceQuota = strategy.ResultModifier{
+	Name:               "resource-quota",
+	RequiredParameters: strategy.RequiredParameters{},
+	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+		quota := app.Quota
+		if prev.Skip || quota == nil || prev.RequiredReplicas <= app.Replicas || prev.RequiredReplicas <= quota.MaxReplicas {
+			return prev, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number (%d) exceeds %d replicas that fit into '%s' resource quota (%s)",
+				app.Name, prev.RequiredReplicas, quota.MaxReplicas, quota.Quota, quota.Resource,
+			)
+		}
+		warning := fmt.Sprintf(
+			"Scaling up to %d replicas is limited by '%s' resource quota: only %d replicas fit into its %s",
+			prev.RequiredReplicas, quota.Quota, quota.MaxReplicas, quota.Resource,
+		)
+		if quota.MaxReplicas <= app.Replicas {
+			return strategy.Result{
+				Skip:       true,
+				SkipReason: "resource quota is exhausted",
+				Warnings:   append(prev.Warnings, warning),
+			}, nil
+		}
+		prev.RequiredReplicas = quota.MaxReplicas
+		prev.Warnings = append(prev.Warnings, warning)
+		return prev, nil
+	},
+}
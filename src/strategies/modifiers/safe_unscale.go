@@ -1,6 +1,7 @@
 package modifiers
 
 import (
+	"fmt"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
@@ -8,27 +9,94 @@ import (
 	"k8s.io/klog"
 )
 
+// SafeUnscale limits scale down to the number of workers that are not processing messages
+// and makes the idlest workers to be removed first. Messages in flight of the workers are looked up
+// only when scaling down and the queue has unacknowledged messages. Without them, the number of idle workers
+// is estimated from the number of unacknowledged messages, and without it scale down is skipped while the queue contains messages
 var SafeUnscale = strategy.ResultModifier{
 	Name: "safe-unscale",
 	RequiredParameters: map[parameter.Name]strategy.ParameterSpec{
-		parameters.QueueLength: {Type: parameter.Int},
-		parameters.SafeUnscale: {Type: parameter.Bool, DefaultValue: true},
+		parameters.QueueLength:            {Type: parameter.Int},
+		parameters.MessagesUnacknowledged: {Type: parameter.Int, DefaultValue: -1},
+		parameters.ConsumersInFlight:      {Type: parameter.IntMap, DefaultValue: map[string]int(nil), Deferred: true},
+		parameters.SafeUnscale:            {Type: parameter.Bool, DefaultValue: true},
+		parameters.SafeUnscaleMaxInFlight: {Type: parameter.Int, DefaultValue: 0},
 	},
 	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
 		safeUnscale := params.Booleans[parameters.SafeUnscale]
 		queueLen := params.Ints[parameters.QueueLength]
+		unacknowledged := params.Ints[parameters.MessagesUnacknowledged]
+		inFlight := params.IntMaps[parameters.ConsumersInFlight]
+		maxInFlight := params.Ints[parameters.SafeUnscaleMaxInFlight]
 
+		if prev.Skip || !safeUnscale || prev.RequiredReplicas >= app.Replicas {
+			return prev, nil
+		}
+		if maxInFlight < 0 {
+			return strategy.Result{}, fmt.Errorf("'%s' must not be negative, got %d", parameters.SafeUnscaleMaxInFlight, maxInFlight)
+		}
+		// All workers are idle
+		if unacknowledged == 0 {
+			return prev, nil
+		}
+		if app.Deferred != nil {
+			deferred, err := app.Deferred.Lookup(parameters.ConsumersInFlight)
+			if err != nil {
+				return strategy.Result{}, fmt.Errorf("failed to look up '%s': %w", parameters.ConsumersInFlight, err)
+			}
+			if deferred.Contains(parameters.ConsumersInFlight, parameter.IntMap) {
+				inFlight = deferred.IntMaps[parameters.ConsumersInFlight]
+			}
+		}
+		removed := app.Replicas - prev.RequiredReplicas
+
+		var idle int
 		switch {
-		case prev.Skip || !safeUnscale:
+		case inFlight != nil:
+			prev.PodDeletionCosts = inFlight
+			// Workers that don't consume from the queue are considered idle
+			idle = app.Replicas - len(inFlight)
+			if idle < 0 {
+				idle = 0
+			}
+			for _, count := range inFlight {
+				if count <= maxInFlight {
+					idle++
+				}
+			}
+		case unacknowledged > 0:
+			// At most this number of workers have more than max in flight messages
+			busy := unacknowledged / (maxInFlight + 1)
+			idle = app.Replicas - busy
+			if idle < 0 {
+				idle = 0
+			}
+		case queueLen == 0:
 			return prev, nil
-		case prev.RequiredReplicas < app.Replicas && queueLen < 0:
+		default:
+			if klog.V(2) {
+				klog.Infof("Skipping %s downscaling as its queue contains messages and safe unscale is enabled", app.Name)
+			}
+			return strategy.Result{Skip: true, SkipReason: "queue contains messages"}, nil
+		}
+
+		switch {
+		case idle == 0:
 			if klog.V(2) {
 				klog.Infof(
-					"Skipping %s downscaling as its queue contains messages and safe unscale is enabled",
+					"Skipping %s downscaling as all its workers are processing messages and safe unscale is enabled",
 					app.Name,
 				)
 			}
-			return strategy.Result{Skip: true}, nil
+			return strategy.Result{Skip: true, SkipReason: "all workers are processing messages"}, nil
+		case idle < removed:
+			if klog.V(2) {
+				klog.Infof(
+					"%s's downscaling is limited to %d idle workers as safe unscale is enabled",
+					app.Name, idle,
+				)
+			}
+			prev.RequiredReplicas = app.Replicas - idle
 		}
 		return prev, nil
 	},
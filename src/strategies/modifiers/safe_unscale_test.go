@@ -0,0 +1,198 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSafeUnscale(t *testing.T) {
+	testCases := []struct {
+		name        string
+		replicas    int
+		queueLen    int
+		unacked     int
+		inFlight    map[string]int
+		maxInFlight int
+		prev        strategy.Result
+		expected    strategy.Result
+	}{
+		{
+			name:     "scale up",
+			replicas: 2,
+			queueLen: 10,
+			unacked:  2,
+			inFlight: map[string]int{"a": 1, "b": 1},
+			prev:     strategy.Result{RequiredReplicas: 4},
+			expected: strategy.Result{RequiredReplicas: 4},
+		},
+		{
+			name:     "idle workers",
+			replicas: 3,
+			queueLen: 10,
+			unacked:  1,
+			inFlight: map[string]int{"a": 0, "b": 0, "c": 1},
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{RequiredReplicas: 1, PodDeletionCosts: map[string]int{"a": 0, "b": 0, "c": 1}},
+		},
+		{
+			name:     "limited by idle workers",
+			replicas: 4,
+			queueLen: 10,
+			unacked:  6,
+			inFlight: map[string]int{"a": 0, "b": 2, "c": 1, "d": 3},
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{RequiredReplicas: 3, PodDeletionCosts: map[string]int{"a": 0, "b": 2, "c": 1, "d": 3}},
+		},
+		{
+			name:        "max in flight",
+			replicas:    4,
+			queueLen:    10,
+			unacked:     6,
+			inFlight:    map[string]int{"a": 0, "b": 2, "c": 1, "d": 3},
+			maxInFlight: 1,
+			prev:        strategy.Result{RequiredReplicas: 1},
+			expected:    strategy.Result{RequiredReplicas: 2, PodDeletionCosts: map[string]int{"a": 0, "b": 2, "c": 1, "d": 3}},
+		},
+		{
+			name:     "workers without consumers are idle",
+			replicas: 3,
+			queueLen: 10,
+			unacked:  1,
+			inFlight: map[string]int{"a": 1},
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{RequiredReplicas: 1, PodDeletionCosts: map[string]int{"a": 1}},
+		},
+		{
+			name:     "all workers are busy",
+			replicas: 2,
+			queueLen: 10,
+			unacked:  2,
+			inFlight: map[string]int{"a": 1, "b": 1},
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{Skip: true, SkipReason: "all workers are processing messages"},
+		},
+		{
+			name:     "no unacknowledged messages",
+			replicas: 3,
+			queueLen: 10,
+			unacked:  0,
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{RequiredReplicas: 1},
+		},
+		{
+			name:     "limited by unacknowledged messages",
+			replicas: 4,
+			queueLen: 10,
+			unacked:  2,
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{RequiredReplicas: 2},
+		},
+		{
+			name:        "limited by unacknowledged messages above max in flight",
+			replicas:    4,
+			queueLen:    10,
+			unacked:     6,
+			maxInFlight: 2,
+			prev:        strategy.Result{RequiredReplicas: 1},
+			expected:    strategy.Result{RequiredReplicas: 2},
+		},
+		{
+			name:     "unacknowledged messages of all workers",
+			replicas: 4,
+			queueLen: 10,
+			unacked:  8,
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{Skip: true, SkipReason: "all workers are processing messages"},
+		},
+		{
+			name:     "without in flight messages and with empty queue",
+			replicas: 2,
+			queueLen: 0,
+			unacked:  -1,
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{RequiredReplicas: 1},
+		},
+		{
+			name:     "without in flight messages and with messages in queue",
+			replicas: 2,
+			queueLen: 10,
+			unacked:  -1,
+			prev:     strategy.Result{RequiredReplicas: 1},
+			expected: strategy.Result{Skip: true, SkipReason: "queue contains messages"},
+		},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Ints[parameters.QueueLength] = tc.queueLen
+		params.Ints[parameters.MessagesUnacknowledged] = tc.unacked
+		params.IntMaps[parameters.ConsumersInFlight] = tc.inFlight
+		params.Booleans[parameters.SafeUnscale] = true
+		params.Ints[parameters.SafeUnscaleMaxInFlight] = tc.maxInFlight
+
+		result, err := SafeUnscale.Execute(scalable.App{Name: "app", Replicas: tc.replicas}, params, tc.prev)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestSafeUnscale_disabled(t *testing.T) {
+	params := parameter.EmptyValues()
+	params.Ints[parameters.QueueLength] = 10
+	params.Booleans[parameters.SafeUnscale] = false
+
+	result, err := SafeUnscale.Execute(scalable.App{Name: "app", Replicas: 2}, params, strategy.Result{RequiredReplicas: 1})
+	require.NoError(t, err)
+	require.Equal(t, strategy.Result{RequiredReplicas: 1}, result)
+}
+
+// deferredValues looks up deferred parameters from the values and counts lookups
+type deferredValues struct {
+	values  parameter.Values
+	lookups int
+}
+
+func (d *deferredValues) Lookup(names ...parameter.Name) (parameter.Values, error) {
+	d.lookups++
+	return d.values, nil
+}
+
+func TestSafeUnscale_deferredInFlight(t *testing.T) {
+	testCases := []struct {
+		name            string
+		unacked         int
+		prev            strategy.Result
+		expected        strategy.Result
+		expectedLookups int
+	}{
+		{
+			name:            "scale down",
+			unacked:         3,
+			prev:            strategy.Result{RequiredReplicas: 1},
+			expected:        strategy.Result{RequiredReplicas: 2, PodDeletionCosts: map[string]int{"a": 0, "b": 1, "c": 2}},
+			expectedLookups: 1,
+		},
+		{name: "scale up", unacked: 3, prev: strategy.Result{RequiredReplicas: 4}, expected: strategy.Result{RequiredReplicas: 4}},
+		{name: "no unacknowledged messages", unacked: 0, prev: strategy.Result{RequiredReplicas: 1}, expected: strategy.Result{RequiredReplicas: 1}},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Ints[parameters.QueueLength] = 10
+		params.Ints[parameters.MessagesUnacknowledged] = tc.unacked
+		params.IntMaps[parameters.ConsumersInFlight] = nil
+		params.Booleans[parameters.SafeUnscale] = true
+		params.Ints[parameters.SafeUnscaleMaxInFlight] = 0
+
+		deferred := &deferredValues{values: parameter.EmptyValues()}
+		deferred.values.IntMaps[parameters.ConsumersInFlight] = map[string]int{"a": 0, "b": 1, "c": 2}
+		app := scalable.App{Name: "app", Replicas: 3, Deferred: deferred}
+
+		result, err := SafeUnscale.Execute(app, params, tc.prev)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+		require.Equal(t, tc.expectedLookups, deferred.lookups, tc.name)
+	}
+}
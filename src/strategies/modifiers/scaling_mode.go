@@ -0,0 +1,51 @@
+package modifiers
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"k8s.io/klog"
+)
+
+const (
+	ScalingModeBoth     = "both"
+	ScalingModeUpOnly   = "up-only"
+	ScalingModeDownOnly = "down-only"
+	ScalingModeOff      = "off"
+)
+
+// ScalingMode limits directions in which app can be scaled. Modifier is expected
+// to be applied directly to the strategy's result
+var ScalingMode = strategy.ResultModifier{
+	Name: "scaling-mode",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.ScalingMode: {Type: parameter.String, DefaultValue: ScalingModeBoth},
+	},
+	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+		mode := params.Strings[parameters.ScalingMode]
+		change := prev.RequiredReplicas - app.Replicas
+
+		var reason string
+
+		switch {
+		case mode != ScalingModeBoth && mode != ScalingModeUpOnly && mode != ScalingModeDownOnly && mode != ScalingModeOff:
+			return strategy.Result{}, fmt.Errorf("unknown scaling mode '%s'", mode)
+		case prev.Skip:
+			return prev, nil
+		case mode == ScalingModeOff:
+			reason = "scaling mode is 'off'"
+		case mode == ScalingModeUpOnly && change < 0:
+			reason = "scaling mode 'up-only' forbids scale down"
+		case mode == ScalingModeDownOnly && change > 0:
+			reason = "scaling mode 'down-only' forbids scale up"
+		default:
+			return prev, nil
+		}
+		if klog.V(2) {
+			klog.Infof("%s's required replicas number (%d) is ignored: %s", app.Name, prev.RequiredReplicas, reason)
+		}
+		return strategy.Result{Skip: true, SkipReason: reason}, nil
+	},
+}
@@ -0,0 +1,59 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScalingMode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     string
+		prev     strategy.Result
+		expected strategy.Result
+	}{
+		{name: "both up", mode: ScalingModeBoth, prev: strategy.Result{RequiredReplicas: 6}, expected: strategy.Result{RequiredReplicas: 6}},
+		{name: "both down", mode: ScalingModeBoth, prev: strategy.Result{RequiredReplicas: 2}, expected: strategy.Result{RequiredReplicas: 2}},
+		{name: "up-only up", mode: ScalingModeUpOnly, prev: strategy.Result{RequiredReplicas: 6}, expected: strategy.Result{RequiredReplicas: 6}},
+		{
+			name:     "up-only down",
+			mode:     ScalingModeUpOnly,
+			prev:     strategy.Result{RequiredReplicas: 2},
+			expected: strategy.Result{Skip: true, SkipReason: "scaling mode 'up-only' forbids scale down"},
+		},
+		{name: "down-only down", mode: ScalingModeDownOnly, prev: strategy.Result{RequiredReplicas: 2}, expected: strategy.Result{RequiredReplicas: 2}},
+		{
+			name:     "down-only up",
+			mode:     ScalingModeDownOnly,
+			prev:     strategy.Result{RequiredReplicas: 6},
+			expected: strategy.Result{Skip: true, SkipReason: "scaling mode 'down-only' forbids scale up"},
+		},
+		{
+			name:     "off",
+			mode:     ScalingModeOff,
+			prev:     strategy.Result{RequiredReplicas: 6},
+			expected: strategy.Result{Skip: true, SkipReason: "scaling mode is 'off'"},
+		},
+		{name: "skipped result", mode: ScalingModeOff, prev: strategy.Result{Skip: true}, expected: strategy.Result{Skip: true}},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Strings[parameters.ScalingMode] = tc.mode
+
+		result, err := ScalingMode.Execute(scalable.App{Name: "app", Replicas: 4}, params, tc.prev)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestScalingMode_unknownMode(t *testing.T) {
+	params := parameter.EmptyValues()
+	params.Strings[parameters.ScalingMode] = "sideways"
+
+	_, err := ScalingMode.Execute(scalable.App{Name: "app", Replicas: 4}, params, strategy.Result{RequiredReplicas: 6})
+	require.Error(t, err)
+}
@@ -1,24 +1,87 @@
 package modifiers
 
 import (
+	"fmt"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
 	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
 	"k8s.io/klog"
 )
 
+// SkipUnstable skips scaling while app's rollout is in progress or more pods than tolerated are starting.
+// Pods that are unschedulable or crash-looping are not expected to become ready, so scaling up is held
+// at the current number of replicas while some pods can't be scheduled and is skipped while some pods are crash-looping
 var SkipUnstable = strategy.ResultModifier{
-	Name:               "skip-unstable",
-	RequiredParameters: strategy.RequiredParameters{},
+	Name: "skip-unstable",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.UnstableTolerance:    {Type: parameter.Int, DefaultValue: 0},
+		parameters.ScaleUpDuringRollout: {Type: parameter.Bool, DefaultValue: true},
+		parameters.CrashLoopRestarts:    {Type: parameter.Int, DefaultValue: 0},
+	},
 	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
-		if app.ReadyReplicas != app.Replicas {
+		tolerance := params.Ints[parameters.UnstableTolerance]
+		scaleUpDuringRollout := params.Booleans[parameters.ScaleUpDuringRollout]
+		crashLoopRestarts := params.Ints[parameters.CrashLoopRestarts]
+
+		if prev.Skip {
+			return prev, nil
+		}
+		scalingUp := prev.RequiredReplicas > app.Replicas
+
+		rollingOut := app.RollingOut()
+		if rollingOut && !(scalingUp && scaleUpDuringRollout) {
+			if klog.V(2) {
+				klog.Infof(
+					"%s's rollout is in progress (%d of %d replicas updated, %d unavailable), skipping scaling",
+					app.Name, app.Rollout.UpdatedReplicas, app.Replicas, app.Rollout.UnavailableReplicas,
+				)
+			}
+			return strategy.Result{Skip: true, SkipReason: "rollout is in progress"}, nil
+		}
+		if app.ReadyReplicas >= app.Replicas {
+			return prev, nil
+		}
+		counts := app.CountPods(crashLoopRestarts)
+
+		if scalingUp && counts.CrashLooping > 0 {
+			if klog.V(2) {
+				klog.Infof("%s has %d crash-looping pods, skipping scaling up", app.Name, counts.CrashLooping)
+			}
+			return strategy.Result{
+				Skip:       true,
+				SkipReason: "app has pods that can't become ready",
+				Warnings: append(prev.Warnings, fmt.Sprintf(
+					"Scaling up to %d replicas is stopped: %d pods are crash-looping",
+					prev.RequiredReplicas, counts.CrashLooping,
+				)),
+			}, nil
+		}
+		if scalingUp && counts.Unschedulable > 0 {
+			// Schedulable pods are fewer than the current replicas, so scaling up is held at the current number
+			// instead of being capped at them, which would scale down the app below the required number
+			if klog.V(2) {
+				klog.Infof(
+					"%s has %d unschedulable pods, its required replicas number (%d) is held at %d",
+					app.Name, counts.Unschedulable, prev.RequiredReplicas, app.Replicas,
+				)
+			}
+			prev.Warnings = append(prev.Warnings, fmt.Sprintf(
+				"Scaling up to %d replicas is held at %d: %d pods can't be scheduled",
+				prev.RequiredReplicas, app.Replicas, counts.Unschedulable,
+			))
+			prev.RequiredReplicas = app.Replicas
+			return prev, nil
+		}
+		// Starting pods are expected during rollout
+		if !rollingOut && counts.Starting > tolerance {
 			if klog.V(2) {
 				klog.Infof(
-					"%s is unstable, skipping scaling",
-					app.Name,
+					"%s is unstable (%d pods are starting), skipping scaling",
+					app.Name, counts.Starting,
 				)
 			}
-			return strategy.Result{Skip: true}, nil
+			return strategy.Result{Skip: true, SkipReason: "app is unstable"}, nil
 		}
 		return prev, nil
 	},
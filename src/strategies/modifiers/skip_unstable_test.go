@@ -0,0 +1,136 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSkipUnstable(t *testing.T) {
+	stable := scalable.RolloutStatus{Generation: 1, ObservedGeneration: 1, UpdatedReplicas: 4}
+	rollingOut := scalable.RolloutStatus{Generation: 2, ObservedGeneration: 2, UpdatedReplicas: 2, UnavailableReplicas: 1}
+
+	testCases := []struct {
+		name                 string
+		replicas             int
+		ready                int
+		rollout              scalable.RolloutStatus
+		pods                 []scalable.PodStatus
+		scaleUpDuringRollout bool
+		prev                 strategy.Result
+		expected             strategy.Result
+	}{
+		{
+			name:     "ready app",
+			ready:    4,
+			rollout:  stable,
+			prev:     strategy.Result{RequiredReplicas: 6},
+			expected: strategy.Result{RequiredReplicas: 6},
+		},
+		{
+			name:     "rollout",
+			ready:    3,
+			rollout:  rollingOut,
+			prev:     strategy.Result{RequiredReplicas: 6},
+			expected: strategy.Result{Skip: true, SkipReason: "rollout is in progress"},
+		},
+		{
+			name:                 "scale up during rollout",
+			ready:                3,
+			rollout:              rollingOut,
+			scaleUpDuringRollout: true,
+			prev:                 strategy.Result{RequiredReplicas: 6},
+			expected:             strategy.Result{RequiredReplicas: 6},
+		},
+		{
+			name:                 "scale down during rollout",
+			ready:                3,
+			rollout:              rollingOut,
+			scaleUpDuringRollout: true,
+			prev:                 strategy.Result{RequiredReplicas: 2},
+			expected:             strategy.Result{Skip: true, SkipReason: "rollout is in progress"},
+		},
+		{
+			name:     "starting pods",
+			ready:    3,
+			rollout:  stable,
+			pods:     []scalable.PodStatus{{Ready: true}, {Ready: true}, {Ready: true}, {}},
+			prev:     strategy.Result{RequiredReplicas: 2},
+			expected: strategy.Result{Skip: true, SkipReason: "app is unstable"},
+		},
+		{
+			name:    "unschedulable pods hold scale up",
+			ready:   3,
+			rollout: stable,
+			pods:    []scalable.PodStatus{{Ready: true}, {Ready: true}, {Ready: true}, {Unschedulable: true}},
+			prev:    strategy.Result{RequiredReplicas: 6},
+			expected: strategy.Result{
+				RequiredReplicas: 4,
+				Warnings:         []string{"Scaling up to 6 replicas is held at 4: 1 pods can't be scheduled"},
+			},
+		},
+		{
+			name:     "unschedulable pods don't scale down above schedulable",
+			replicas: 6,
+			ready:    2,
+			rollout:  scalable.RolloutStatus{Generation: 1, ObservedGeneration: 1, UpdatedReplicas: 6},
+			pods: []scalable.PodStatus{
+				{Ready: true}, {Ready: true}, {Unschedulable: true}, {Unschedulable: true}, {Unschedulable: true}, {Unschedulable: true},
+			},
+			prev: strategy.Result{RequiredReplicas: 8},
+			expected: strategy.Result{
+				RequiredReplicas: 6,
+				Warnings:         []string{"Scaling up to 8 replicas is held at 6: 4 pods can't be scheduled"},
+			},
+		},
+		{
+			name:     "unschedulable pods don't prevent scale down",
+			ready:    3,
+			rollout:  stable,
+			pods:     []scalable.PodStatus{{Ready: true}, {Ready: true}, {Ready: true}, {Unschedulable: true}},
+			prev:     strategy.Result{RequiredReplicas: 2},
+			expected: strategy.Result{RequiredReplicas: 2},
+		},
+		{
+			name:    "crash-looping pods stop scale up",
+			ready:   3,
+			rollout: stable,
+			pods:    []scalable.PodStatus{{Ready: true}, {Ready: true}, {Ready: true}, {CrashLooping: true}},
+			prev:    strategy.Result{RequiredReplicas: 6},
+			expected: strategy.Result{
+				Skip:       true,
+				SkipReason: "app has pods that can't become ready",
+				Warnings:   []string{"Scaling up to 6 replicas is stopped: 1 pods are crash-looping"},
+			},
+		},
+		{
+			name:     "crash-looping pods don't prevent scale down",
+			ready:    3,
+			rollout:  stable,
+			pods:     []scalable.PodStatus{{Ready: true}, {Ready: true}, {Ready: true}, {Restarts: 5}},
+			prev:     strategy.Result{RequiredReplicas: 2},
+			expected: strategy.Result{RequiredReplicas: 2},
+		},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Ints[parameters.UnstableTolerance] = 0
+		params.Booleans[parameters.ScaleUpDuringRollout] = tc.scaleUpDuringRollout
+		params.Ints[parameters.CrashLoopRestarts] = 5
+
+		replicas := tc.replicas
+		if replicas == 0 {
+			replicas = 4
+		}
+		app := scalable.App{Name: "app", Replicas: replicas, ReadyReplicas: tc.ready, Rollout: tc.rollout}
+		if tc.pods != nil {
+			app.Pods = &tc.pods
+		}
+		result, err := SkipUnstable.Execute(app, params, tc.prev)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
@@ -0,0 +1,109 @@
+package modifiers
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"k8s.io/klog"
+	"time"
+)
+
+const stabilizationStateName = "stabilization"
+
+type recommendation struct {
+	Time     time.Time
+	Replicas int
+}
+
+// Stabilization keeps app's recommendations for the longest of configured windows. Scale down is performed
+// to the maximum recommendation within the scale down window, scale up - to the minimum recommendation
+// within the scale up window. Stabilized number of replicas never reverses the recommended scaling direction.
+// Modifier is expected to be applied directly to the strategy's result
+var Stabilization = strategy.ResultModifier{
+	Name: "stabilization",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.ScaleDownStabilizationWindow: {Type: parameter.Duration, DefaultValue: time.Duration(0)},
+		parameters.ScaleUpStabilizationWindow:   {Type: parameter.Duration, DefaultValue: time.Duration(0)},
+	},
+	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+		downWindow := params.Durations[parameters.ScaleDownStabilizationWindow]
+		upWindow := params.Durations[parameters.ScaleUpStabilizationWindow]
+
+		if downWindow <= 0 && upWindow <= 0 {
+			return prev, nil
+		}
+		recommended := prev.RequiredReplicas
+		if prev.Skip {
+			recommended = app.Replicas
+		}
+		now := time.Now()
+
+		var history []recommendation
+		if _, err := app.State.Get(stabilizationStateName, &history); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to load recommendations: %w", err)
+		}
+		history = append(keepWithin(history, now, maxDuration(downWindow, upWindow)), recommendation{Time: now, Replicas: recommended})
+
+		if err := app.State.Set(stabilizationStateName, history); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to save recommendations: %w", err)
+		}
+		stabilized := recommended
+
+		switch {
+		case recommended < app.Replicas && downWindow > 0:
+			for _, r := range keepWithin(history, now, downWindow) {
+				if r.Replicas > stabilized {
+					stabilized = r.Replicas
+				}
+			}
+			if stabilized > app.Replicas {
+				stabilized = app.Replicas
+			}
+		case recommended > app.Replicas && upWindow > 0:
+			for _, r := range keepWithin(history, now, upWindow) {
+				if r.Replicas < stabilized {
+					stabilized = r.Replicas
+				}
+			}
+			if stabilized < app.Replicas {
+				stabilized = app.Replicas
+			}
+		default:
+			return prev, nil
+		}
+		if stabilized == recommended {
+			return prev, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number (%d) is stabilized to %d by recommendations within stabilization window",
+				app.Name, recommended, stabilized,
+			)
+		}
+		if stabilized == app.Replicas {
+			prev.RequiredReplicas, prev.Skip, prev.SkipReason = 0, true, "scale is prevented by stabilization window"
+			return prev, nil
+		}
+		prev.RequiredReplicas = stabilized
+		return prev, nil
+	},
+}
+
+func keepWithin(history []recommendation, now time.Time, window time.Duration) []recommendation {
+	kept := make([]recommendation, 0, len(history))
+	for _, r := range history {
+		if now.Sub(r.Time) <= window {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
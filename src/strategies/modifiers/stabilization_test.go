@@ -0,0 +1,101 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestStabilization(t *testing.T) {
+	testCases := []struct {
+		name     string
+		replicas int
+		history  []int
+		prev     strategy.Result
+		expected strategy.Result
+	}{
+		{
+			name:     "scale down to maximum within window",
+			replicas: 5,
+			history:  []int{4, 3},
+			prev:     strategy.Result{RequiredReplicas: 2, Warnings: []string{"warning"}},
+			expected: strategy.Result{RequiredReplicas: 4, Warnings: []string{"warning"}},
+		},
+		{
+			name:     "scale down isn't reversed to scale up",
+			replicas: 5,
+			history:  []int{8, 3},
+			prev:     strategy.Result{RequiredReplicas: 2},
+			expected: strategy.Result{Skip: true, SkipReason: "scale is prevented by stabilization window"},
+		},
+		{
+			name:     "scale up to minimum within window",
+			replicas: 2,
+			history:  []int{4, 6},
+			prev:     strategy.Result{RequiredReplicas: 8},
+			expected: strategy.Result{RequiredReplicas: 4},
+		},
+		{
+			name:     "scale up isn't reversed to scale down",
+			replicas: 4,
+			history:  []int{1, 6},
+			prev:     strategy.Result{RequiredReplicas: 8},
+			expected: strategy.Result{Skip: true, SkipReason: "scale is prevented by stabilization window"},
+		},
+		{
+			name:     "recommendation is already stable",
+			replicas: 5,
+			history:  []int{2, 1},
+			prev:     strategy.Result{RequiredReplicas: 3},
+			expected: strategy.Result{RequiredReplicas: 3},
+		},
+		{
+			name:     "skipped result",
+			replicas: 5,
+			history:  []int{8},
+			prev:     strategy.Result{Skip: true},
+			expected: strategy.Result{Skip: true},
+		},
+	}
+	for _, tc := range testCases {
+		appState := state.For(state.NewMemoryStore(), "ns/app")
+		history := make([]recommendation, 0, len(tc.history))
+		for _, replicas := range tc.history {
+			history = append(history, recommendation{Time: time.Now().Add(-time.Minute), Replicas: replicas})
+		}
+		require.NoError(t, appState.Set(stabilizationStateName, history), tc.name)
+
+		params := parameter.EmptyValues()
+		params.Durations[parameters.ScaleDownStabilizationWindow] = 5 * time.Minute
+		params.Durations[parameters.ScaleUpStabilizationWindow] = 5 * time.Minute
+
+		app := scalable.App{Key: "ns/app", Name: "app", Replicas: tc.replicas, State: appState}
+		result, err := Stabilization.Execute(app, params, tc.prev)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestStabilization_forgetsOutdatedRecommendations(t *testing.T) {
+	appState := state.For(state.NewMemoryStore(), "ns/app")
+	history := []recommendation{{Time: time.Now().Add(-10 * time.Minute), Replicas: 5}}
+	require.NoError(t, appState.Set(stabilizationStateName, history))
+
+	params := parameter.EmptyValues()
+	params.Durations[parameters.ScaleDownStabilizationWindow] = 5 * time.Minute
+	params.Durations[parameters.ScaleUpStabilizationWindow] = 0
+
+	app := scalable.App{Key: "ns/app", Name: "app", Replicas: 5, State: appState}
+	result, err := Stabilization.Execute(app, params, strategy.Result{RequiredReplicas: 2})
+	require.NoError(t, err)
+	require.Equal(t, strategy.Result{RequiredReplicas: 2}, result)
+
+	_, err = appState.Get(stabilizationStateName, &history)
+	require.NoError(t, err)
+	require.Len(t, history, 1, "Expected recommendations outside of the windows to be removed")
+}
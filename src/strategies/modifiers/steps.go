@@ -34,7 +34,8 @@ var WithSteps = strategy.ResultModifier{
 				app.Name, scale, maxStep, reqRepl,
 			)
 		}
-		return strategy.Result{RequiredReplicas: reqRepl}, nil
+		prev.RequiredReplicas = reqRepl
+		return prev, nil
 	},
 }
 
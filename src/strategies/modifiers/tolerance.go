@@ -0,0 +1,52 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"k8s.io/klog"
+)
+
+// Tolerance skips changes of replicas number that don't exceed either the absolute
+// or the relative to the current replicas number tolerance in the scaling direction
+var Tolerance = strategy.ResultModifier{
+	Name: "tolerance",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.ScaleUpTolerance:           {Type: parameter.Float, DefaultValue: 0.},
+		parameters.ScaleDownTolerance:         {Type: parameter.Float, DefaultValue: 0.},
+		parameters.ScaleUpAbsoluteTolerance:   {Type: parameter.Int, DefaultValue: 0},
+		parameters.ScaleDownAbsoluteTolerance: {Type: parameter.Int, DefaultValue: 0},
+	},
+	Execute: func(app scalable.App, params parameter.Values, prev strategy.Result) (strategy.Result, error) {
+		if prev.Skip {
+			return prev, nil
+		}
+		change := prev.RequiredReplicas - app.Replicas
+
+		var relative float64
+		var absolute int
+
+		switch {
+		case change > 0:
+			relative, absolute = params.Floats[parameters.ScaleUpTolerance], params.Ints[parameters.ScaleUpAbsoluteTolerance]
+		case change < 0:
+			relative, absolute = params.Floats[parameters.ScaleDownTolerance], params.Ints[parameters.ScaleDownAbsoluteTolerance]
+			change = -change
+		default:
+			return prev, nil
+		}
+		withinRelative := app.Replicas > 0 && float64(change)/float64(app.Replicas) <= relative
+
+		if change > absolute && !withinRelative {
+			return prev, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas change (%d -> %d) is within tolerance (absolute %d, relative %.2f), skipping scaling",
+				app.Name, app.Replicas, prev.RequiredReplicas, absolute, relative,
+			)
+		}
+		return strategy.Result{Skip: true, SkipReason: "replicas change is within tolerance"}, nil
+	},
+}
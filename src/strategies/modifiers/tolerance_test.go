@@ -0,0 +1,56 @@
+package modifiers
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTolerance(t *testing.T) {
+	skipped := strategy.Result{Skip: true, SkipReason: "replicas change is within tolerance"}
+
+	testCases := []struct {
+		name         string
+		replicas     int
+		required     int
+		upRelative   float64
+		downRelative float64
+		upAbsolute   int
+		downAbsolute int
+		expected     strategy.Result
+	}{
+		{name: "no tolerance", replicas: 10, required: 11, expected: strategy.Result{RequiredReplicas: 11}},
+		{name: "within relative up", replicas: 10, required: 11, upRelative: 0.1, expected: skipped},
+		{name: "above relative up", replicas: 10, required: 12, upRelative: 0.1, expected: strategy.Result{RequiredReplicas: 12}},
+		{name: "within absolute up", replicas: 10, required: 12, upAbsolute: 2, expected: skipped},
+		{name: "above absolute up", replicas: 10, required: 13, upAbsolute: 2, expected: strategy.Result{RequiredReplicas: 13}},
+		{name: "within relative down", replicas: 10, required: 8, downRelative: 0.2, expected: skipped},
+		{name: "within absolute down", replicas: 10, required: 9, downAbsolute: 1, expected: skipped},
+		{name: "above absolute down", replicas: 10, required: 8, downAbsolute: 1, expected: strategy.Result{RequiredReplicas: 8}},
+		{
+			name:         "tolerance of other direction",
+			replicas:     10,
+			required:     9,
+			upRelative:   0.5,
+			upAbsolute:   5,
+			downRelative: 0,
+			expected:     strategy.Result{RequiredReplicas: 9},
+		},
+		{name: "relative tolerance without replicas", replicas: 0, required: 1, upRelative: 1, expected: strategy.Result{RequiredReplicas: 1}},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Floats[parameters.ScaleUpTolerance] = tc.upRelative
+		params.Floats[parameters.ScaleDownTolerance] = tc.downRelative
+		params.Ints[parameters.ScaleUpAbsoluteTolerance] = tc.upAbsolute
+		params.Ints[parameters.ScaleDownAbsoluteTolerance] = tc.downAbsolute
+
+		app := scalable.App{Name: "app", Replicas: tc.replicas}
+		result, err := Tolerance.Execute(app, params, strategy.Result{RequiredReplicas: tc.required})
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
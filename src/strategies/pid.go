@@ -0,0 +1,126 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
+	"k8s.io/klog"
+	"math"
+	"time"
+)
+
+// QueueLengthPID drives queue length towards the setpoint (in messages)
+var QueueLengthPID = newPID(
+	"pid-queue-length",
+	parameters.QueueLength, parameter.Int,
+	func(params parameter.Values) float64 {
+		return float64(params.Ints[parameters.QueueLength])
+	},
+)
+
+// MessageAgePID drives the age of the queue's head message towards the setpoint (in seconds)
+var MessageAgePID = newPID(
+	"pid-message-age",
+	parameters.HeadMessageAge, parameter.Duration,
+	func(params parameter.Values) float64 {
+		return params.Durations[parameters.HeadMessageAge].Seconds()
+	},
+)
+
+type pidState struct {
+	Bias      float64
+	Integral  float64
+	PrevError float64
+	PrevTime  time.Time
+}
+
+// newPID creates strategy which output is the required number of replicas. The output is biased by the number
+// of replicas app had when controller state was initialized, so that the first decision doesn't cause a jump
+func newPID(name strategy.Name, metric parameter.Name, metricType parameter.Type, metricValue func(parameter.Values) float64) strategy.Config {
+	return strategy.Config{
+		Name:     name,
+		YAMLName: strategy.YAMLName(name),
+		RequiredParameters: strategy.RequiredParameters{
+			metric:                 {Type: metricType},
+			parameters.PIDSetpoint: {Type: parameter.Float},
+			parameters.PIDKp:       {Type: parameter.Float},
+			parameters.PIDKi:       {Type: parameter.Float, DefaultValue: 0.},
+			parameters.PIDKd:       {Type: parameter.Float, DefaultValue: 0.},
+			parameters.Min:         {Type: parameter.Int},
+			parameters.Max:         {Type: parameter.Int},
+		},
+		ResultModifiers: []strategy.ResultModifier{
+			modifiers.ScalingMode,
+			modifiers.Stabilization,
+			modifiers.Tolerance,
+			modifiers.WithSteps,
+			modifiers.MinMax,
+			modifiers.PartitionCap,
+			modifiers.ResourceQuota,
+			modifiers.SkipUnstable,
+			modifiers.OverrideLimits,
+			modifiers.Cooldown,
+			modifiers.Behavior,
+		},
+		Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+			value, setpoint := metricValue(params), params.Floats[parameters.PIDSetpoint]
+			kp, ki, kd := params.Floats[parameters.PIDKp], params.Floats[parameters.PIDKi], params.Floats[parameters.PIDKd]
+			min, max := float64(params.Ints[parameters.Min]), float64(params.Ints[parameters.Max])
+			now := time.Now()
+
+			var state pidState
+			found, err := app.State.Get(string(name), &state)
+			if err != nil {
+				return strategy.Result{}, fmt.Errorf("failed to load controller state: %w", err)
+			}
+			if !found {
+				state = pidState{Bias: float64(app.Replicas)}
+			}
+			e := value - setpoint
+			integral, derivative := state.Integral, 0.
+
+			if found {
+				if dt := now.Sub(state.PrevTime).Seconds(); dt > 0 {
+					integral += e * dt
+					derivative = (e - state.PrevError) / dt
+				}
+			}
+			output := state.Bias + kp*e + ki*integral + kd*derivative
+
+			// Integral isn't accumulated while output is saturated to avoid windup
+			if (output > max && e > 0) || (output < min && e < 0) {
+				integral = state.Integral
+				output = state.Bias + kp*e + ki*integral + kd*derivative
+			}
+			output = math.Max(min, math.Min(max, output))
+
+			state.Integral, state.PrevError, state.PrevTime = integral, e, now
+			if err := app.State.Set(string(name), state); err != nil {
+				return strategy.Result{}, fmt.Errorf("failed to save controller state: %w", err)
+			}
+			reqRepl := int(math.Round(output))
+
+			if reqRepl == app.Replicas {
+				if klog.V(2) {
+					klog.Infof(
+						"%s's required replicas number is equal to its current replicas (%d), skipping scaling",
+						app.Name, app.Replicas,
+					)
+				}
+				return strategy.Result{Skip: true}, nil
+			}
+			if klog.V(2) {
+				klog.Infof(
+					"%s's required replicas number will be changed to %d. "+
+						"Parameters: current replicas - %d, %s - %.2f, setpoint - %.2f, "+
+						"error - %.2f, integral - %.2f, derivative - %.2f",
+					app.Name, reqRepl, app.Replicas, metric, value, setpoint, e, integral, derivative,
+				)
+			}
+			return strategy.Result{RequiredReplicas: reqRepl}, nil
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package strategies
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestQueueLengthPID_proportional(t *testing.T) {
+	testCases := []struct {
+		name        string
+		replicas    int
+		queueLength int
+		expected    strategy.Result
+	}{
+		{
+			name:        "scale up biased by current replicas",
+			replicas:    2,
+			queueLength: 10,
+			expected:    strategy.Result{RequiredReplicas: 5},
+		},
+		{
+			name:        "scale down biased by current replicas",
+			replicas:    6,
+			queueLength: 0,
+			expected:    strategy.Result{RequiredReplicas: 4},
+		},
+		{
+			name:        "limited by max",
+			replicas:    8,
+			queueLength: 100,
+			expected:    strategy.Result{RequiredReplicas: 10},
+		},
+		{
+			name:        "limited by min",
+			replicas:    2,
+			queueLength: 0,
+			expected:    strategy.Result{RequiredReplicas: 1},
+		},
+		{
+			name:        "setpoint reached",
+			replicas:    3,
+			queueLength: 4,
+			expected:    strategy.Result{Skip: true},
+		},
+	}
+	for _, tc := range testCases {
+		params := parameter.EmptyValues()
+		params.Ints[parameters.QueueLength] = tc.queueLength
+		params.Floats[parameters.PIDSetpoint] = 4
+		params.Floats[parameters.PIDKp] = 0.5
+		params.Ints[parameters.Min] = 1
+		params.Ints[parameters.Max] = 10
+
+		app := scalable.App{Key: "ns/app", Name: "app", Replicas: tc.replicas, State: state.For(state.NewMemoryStore(), "ns/app")}
+		result, err := QueueLengthPID.Execute(app, params)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestQueueLengthPID_keepsBias(t *testing.T) {
+	params := parameter.EmptyValues()
+	params.Ints[parameters.QueueLength] = 10
+	params.Floats[parameters.PIDSetpoint] = 4
+	params.Floats[parameters.PIDKp] = 0.5
+	params.Ints[parameters.Min] = 1
+	params.Ints[parameters.Max] = 10
+
+	app := scalable.App{Key: "ns/app", Name: "app", Replicas: 2, State: state.For(state.NewMemoryStore(), "ns/app")}
+	result, err := QueueLengthPID.Execute(app, params)
+	require.NoError(t, err)
+	require.Equal(t, 5, result.RequiredReplicas)
+
+	// The bias is kept from the first round, so the output depends on the error only
+	app.Replicas = 5
+	result, err = QueueLengthPID.Execute(app, params)
+	require.NoError(t, err)
+	require.True(t, result.Skip)
+}
+
+func TestQueueLengthPID_withoutStateStore(t *testing.T) {
+	params := parameter.EmptyValues()
+	params.Floats[parameters.PIDKp] = 0.5
+
+	_, err := QueueLengthPID.Execute(scalable.App{Name: "app"}, params)
+	require.ErrorIs(t, err, state.ErrNotConfigured)
+}
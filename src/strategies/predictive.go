@@ -0,0 +1,206 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/metrics"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/medal-labs/k8s-rmq-autoscaler/strategies/modifiers"
+	"k8s.io/klog"
+	"math"
+	"time"
+)
+
+const (
+	forecastStateName        = "forecast"
+	forecastCurrentStateName = "forecast-current"
+)
+
+// PredictiveQueueBased keeps seasonal profiles of app's queue length and publish rate and requires
+// the maximum of the replicas needed for the current queue length and for the load predicted
+// at the end of the forecast lead time
+var PredictiveQueueBased = strategy.Config{
+	Name:     "predictive-queue-based",
+	YAMLName: "predictive-queue-based",
+	RequiredParameters: strategy.RequiredParameters{
+		parameters.MessagesPerWorker: {Type: parameter.Int, DefaultValue: 1},
+		parameters.Offset:            {Type: parameter.Int, DefaultValue: 2},
+		parameters.QueueLength:       {Type: parameter.Int},
+		parameters.PublishRate:       {Type: parameter.Float},
+		parameters.DeliverRate:       {Type: parameter.Float},
+		parameters.ForecastLead:      {Type: parameter.Duration, DefaultValue: 10 * time.Minute},
+		parameters.ForecastSeason:    {Type: parameter.Duration, DefaultValue: 24 * time.Hour},
+		parameters.ForecastBucket:    {Type: parameter.Duration, DefaultValue: 5 * time.Minute},
+		parameters.ForecastSmoothing: {Type: parameter.Float, DefaultValue: 0.3},
+	},
+	ResultModifiers: []strategy.ResultModifier{
+		modifiers.ScalingMode,
+		modifiers.Stabilization,
+		modifiers.Tolerance,
+		modifiers.WithSteps,
+		modifiers.MinMax,
+		modifiers.PartitionCap,
+		modifiers.ResourceQuota,
+		modifiers.SkipUnstable,
+		modifiers.OverrideLimits,
+		modifiers.SafeUnscale,
+		modifiers.Cooldown,
+		modifiers.Behavior,
+	},
+	Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
+		queueLen, messagesPerWorker := float64(params.Ints[parameters.QueueLength]), float64(params.Ints[parameters.MessagesPerWorker])
+		publishRate, deliverRate := params.Floats[parameters.PublishRate], params.Floats[parameters.DeliverRate]
+		offset := params.Ints[parameters.Offset]
+		lead := params.Durations[parameters.ForecastLead]
+		season, bucket := params.Durations[parameters.ForecastSeason], params.Durations[parameters.ForecastBucket]
+		smoothing := params.Floats[parameters.ForecastSmoothing]
+
+		if bucket <= 0 || season < bucket || season%bucket != 0 {
+			return strategy.Result{}, fmt.Errorf(
+				"'%s' must be positive and divide '%s', got %s and %s",
+				parameters.ForecastBucket, parameters.ForecastSeason, bucket, season,
+			)
+		}
+		now := time.Now()
+
+		var state forecastState
+		if _, err := app.State.Get(forecastStateName, &state); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to load forecast state: %w", err)
+		}
+		var current forecastBucket
+		if _, err := app.State.Get(forecastCurrentStateName, &current); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to load forecast state: %w", err)
+		}
+		if state.Season != season || state.Bucket != bucket {
+			// Collected profiles are useless with different seasonality
+			state, current = newForecastState(season, bucket), forecastBucket{}
+		}
+		// Profiles change only when a bucket is over, so they're saved much less often than the current bucket
+		if index := state.bucketOf(now); index != current.Index {
+			if current.Count > 0 {
+				state.fold(current, smoothing)
+				if err := app.State.Set(forecastStateName, state); err != nil {
+					return strategy.Result{}, fmt.Errorf("failed to save forecast state: %w", err)
+				}
+			}
+			current = forecastBucket{Index: index}
+		}
+		current.QueueLength += queueLen
+		current.PublishRate += publishRate
+		current.Count++
+
+		if err := app.State.Set(forecastCurrentStateName, current); err != nil {
+			return strategy.Result{}, fmt.Errorf("failed to save forecast state: %w", err)
+		}
+		target := now.Add(lead)
+		forecastQueueLen, _ := state.QueueLength.forecast(state.bucketOf(target))
+		forecastRate, hasRate := state.PublishRate.forecast(state.bucketOf(target))
+
+		if hasRate && forecastRate > deliverRate {
+			// Messages that current consumers won't keep up with when publish rate grows as predicted
+			forecastQueueLen = math.Max(forecastQueueLen, queueLen+(forecastRate-deliverRate)*lead.Seconds())
+		}
+		reactiveRepl := queueBasedReplicas(queueLen, messagesPerWorker, offset)
+		predictedRepl := queueBasedReplicas(forecastQueueLen, messagesPerWorker, offset)
+
+		metrics.ForecastQueueLength.WithLabelValues(app.Key).Set(forecastQueueLen)
+		metrics.ForecastPublishRate.WithLabelValues(app.Key).Set(forecastRate)
+		metrics.ForecastRequiredReplicas.WithLabelValues(app.Key).Set(float64(predictedRepl))
+
+		if klog.V(2) {
+			klog.Infof(
+				"%s's forecast for %s: queue length - %.1f, publish rate - %.2f, required replicas - %d "+
+					"(reactive required replicas - %d)",
+				app.Name, target.Format(time.RFC3339), forecastQueueLen, forecastRate, predictedRepl, reactiveRepl,
+			)
+		}
+		reqRepl := maxInt(reactiveRepl, predictedRepl)
+
+		if reqRepl == app.Replicas {
+			if klog.V(2) {
+				klog.Infof(
+					"%s's required replicas number is equal to its current replicas (%d), skipping scaling",
+					app.Name, app.Replicas,
+				)
+			}
+			return strategy.Result{Skip: true}, nil
+		}
+		if klog.V(2) {
+			klog.Infof(
+				"%s's required replicas number will be changed to %d. "+
+					"Parameters: current replicas - %d, queue length - %d, publish rate - %.2f, "+
+					"deliver rate - %.2f, messages per worker - %d, offset - %d",
+				app.Name, reqRepl, app.Replicas, int(queueLen), publishRate, deliverRate, int(messagesPerWorker), offset,
+			)
+		}
+		return strategy.Result{RequiredReplicas: reqRepl}, nil
+	},
+}
+
+type forecastState struct {
+	Season      time.Duration
+	Bucket      time.Duration
+	QueueLength seasonalProfile
+	PublishRate seasonalProfile
+}
+
+// forecastBucket accumulates values observed while the current bucket lasts
+type forecastBucket struct {
+	Index       int64
+	QueueLength float64
+	PublishRate float64
+	Count       int
+}
+
+// seasonalProfile keeps exponentially smoothed averages of values observed in each bucket of the season
+type seasonalProfile struct {
+	Averages []float64
+	Seen     []bool
+}
+
+func newForecastState(season time.Duration, bucket time.Duration) forecastState {
+	buckets := int(season / bucket)
+	return forecastState{
+		Season:      season,
+		Bucket:      bucket,
+		QueueLength: newSeasonalProfile(buckets),
+		PublishRate: newSeasonalProfile(buckets),
+	}
+}
+
+func (s forecastState) bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(s.Bucket)
+}
+
+// fold adds means of the finished bucket to the profiles
+func (s *forecastState) fold(b forecastBucket, smoothing float64) {
+	s.QueueLength.observe(b.Index, b.QueueLength/float64(b.Count), smoothing)
+	s.PublishRate.observe(b.Index, b.PublishRate/float64(b.Count), smoothing)
+}
+
+func newSeasonalProfile(buckets int) seasonalProfile {
+	return seasonalProfile{
+		Averages: make([]float64, buckets),
+		Seen:     make([]bool, buckets),
+	}
+}
+
+func (p *seasonalProfile) observe(bucket int64, mean float64, smoothing float64) {
+	slot := p.slot(bucket)
+	if p.Seen[slot] {
+		p.Averages[slot] = smoothing*mean + (1-smoothing)*p.Averages[slot]
+	} else {
+		p.Averages[slot], p.Seen[slot] = mean, true
+	}
+}
+
+func (p seasonalProfile) forecast(bucket int64) (float64, bool) {
+	slot := p.slot(bucket)
+	return p.Averages[slot], p.Seen[slot]
+}
+
+func (p seasonalProfile) slot(bucket int64) int {
+	return int(bucket % int64(len(p.Averages)))
+}
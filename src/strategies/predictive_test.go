@@ -0,0 +1,142 @@
+package strategies
+
+import (
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/parameter"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/scalable"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/state"
+	"github.com/medal-labs/k8s-rmq-autoscaler/base/strategy"
+	"github.com/medal-labs/k8s-rmq-autoscaler/parameters"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func predictiveParams(queueLen int) parameter.Values {
+	params := parameter.EmptyValues()
+	params.Ints[parameters.QueueLength] = queueLen
+	params.Ints[parameters.MessagesPerWorker] = 10
+	params.Ints[parameters.Offset] = 0
+	params.Floats[parameters.PublishRate] = 0
+	params.Floats[parameters.DeliverRate] = 0
+	params.Durations[parameters.ForecastLead] = 10 * time.Minute
+	params.Durations[parameters.ForecastSeason] = 24 * time.Hour
+	params.Durations[parameters.ForecastBucket] = time.Hour
+	params.Floats[parameters.ForecastSmoothing] = 0.5
+	return params
+}
+
+func TestPredictiveQueueBased(t *testing.T) {
+	testCases := []struct {
+		name     string
+		replicas int
+		queueLen int
+		profile  float64
+		seen     bool
+		expected strategy.Result
+	}{
+		{
+			name:     "reactive without profile",
+			replicas: 1,
+			queueLen: 50,
+			expected: strategy.Result{RequiredReplicas: 5},
+		},
+		{
+			name:     "predicted load",
+			replicas: 1,
+			queueLen: 5,
+			profile:  100,
+			seen:     true,
+			expected: strategy.Result{RequiredReplicas: 10},
+		},
+		{
+			name:     "current load above prediction",
+			replicas: 1,
+			queueLen: 200,
+			profile:  100,
+			seen:     true,
+			expected: strategy.Result{RequiredReplicas: 20},
+		},
+		{
+			name:     "equal to current replicas",
+			replicas: 10,
+			queueLen: 5,
+			profile:  100,
+			seen:     true,
+			expected: strategy.Result{Skip: true},
+		},
+	}
+	for _, tc := range testCases {
+		appState := state.For(state.NewMemoryStore(), "ns/app")
+		profile := newForecastState(24*time.Hour, time.Hour)
+		for i := range profile.QueueLength.Averages {
+			profile.QueueLength.Averages[i], profile.QueueLength.Seen[i] = tc.profile, tc.seen
+		}
+		require.NoError(t, appState.Set(forecastStateName, profile), tc.name)
+
+		app := scalable.App{Key: "ns/app", Name: "app", Replicas: tc.replicas, State: appState}
+		result, err := PredictiveQueueBased.Execute(app, predictiveParams(tc.queueLen))
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, result, tc.name)
+	}
+}
+
+func TestPredictiveQueueBased_foldsFinishedBucket(t *testing.T) {
+	store := state.NewMemoryStore()
+	appState := state.For(store, "ns/app")
+	require.NoError(t, appState.Set(forecastStateName, newForecastState(24*time.Hour, time.Hour)))
+	require.NoError(t, appState.Set(forecastCurrentStateName, forecastBucket{Index: 5, QueueLength: 30, Count: 2}))
+
+	app := scalable.App{Key: "ns/app", Name: "app", Replicas: 1, State: appState}
+	_, err := PredictiveQueueBased.Execute(app, predictiveParams(40))
+	require.NoError(t, err)
+
+	var profile forecastState
+	_, err = appState.Get(forecastStateName, &profile)
+	require.NoError(t, err)
+	require.True(t, profile.QueueLength.Seen[5])
+	require.Equal(t, 15., profile.QueueLength.Averages[5])
+
+	var current forecastBucket
+	_, err = appState.Get(forecastCurrentStateName, &current)
+	require.NoError(t, err)
+	require.Equal(t, profile.bucketOf(time.Now()), current.Index)
+	require.Equal(t, 40., current.QueueLength)
+	require.Equal(t, 1, current.Count)
+}
+
+func TestPredictiveQueueBased_invalidBuckets(t *testing.T) {
+	testCases := []struct {
+		name   string
+		season time.Duration
+		bucket time.Duration
+	}{
+		{name: "zero bucket", season: time.Hour, bucket: 0},
+		{name: "bucket longer than season", season: time.Hour, bucket: 2 * time.Hour},
+		{name: "season isn't multiple of bucket", season: 24 * time.Hour, bucket: 7 * time.Minute},
+	}
+	for _, tc := range testCases {
+		params := predictiveParams(0)
+		params.Durations[parameters.ForecastSeason] = tc.season
+		params.Durations[parameters.ForecastBucket] = tc.bucket
+
+		app := scalable.App{Key: "ns/app", Name: "app", State: state.For(state.NewMemoryStore(), "ns/app")}
+		_, err := PredictiveQueueBased.Execute(app, params)
+		require.Error(t, err, tc.name)
+	}
+}
+
+func TestSeasonalProfile(t *testing.T) {
+	profile := newSeasonalProfile(4)
+
+	_, seen := profile.forecast(1)
+	require.False(t, seen)
+
+	profile.observe(1, 10, 0.5)
+	average, seen := profile.forecast(1)
+	require.True(t, seen)
+	require.Equal(t, 10., average, "Expected first mean to be used as is")
+
+	profile.observe(5, 20, 0.5)
+	average, _ = profile.forecast(9)
+	require.Equal(t, 15., average, "Expected buckets of next seasons to be smoothed into the same slot")
+}
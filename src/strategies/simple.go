@@ -19,19 +19,25 @@ var SimpleQueueBased = strategy.Config{
 		parameters.QueueLength:       {Type: parameter.Int},
 	},
 	ResultModifiers: []strategy.ResultModifier{
+		modifiers.ScalingMode,
+		modifiers.Stabilization,
+		modifiers.Tolerance,
 		modifiers.WithSteps,
 		modifiers.MinMax,
+		modifiers.PartitionCap,
+		modifiers.ResourceQuota,
 		modifiers.SkipUnstable,
 		modifiers.OverrideLimits,
 		modifiers.SafeUnscale,
 		modifiers.Cooldown,
+		modifiers.Behavior,
 	},
 	Execute: func(app scalable.App, params parameter.Values) (strategy.Result, error) {
 
 		queueLen, messagesPerWorker := float64(params.Ints[parameters.QueueLength]), float64(params.Ints[parameters.MessagesPerWorker])
 		offset := params.Ints[parameters.Offset]
 
-		reqRepl := int(math.Ceil(queueLen/messagesPerWorker)) + offset
+		reqRepl := queueBasedReplicas(queueLen, messagesPerWorker, offset)
 
 		if reqRepl == app.Replicas {
 			if klog.V(2) {
@@ -53,3 +59,7 @@ var SimpleQueueBased = strategy.Config{
 		return strategy.Result{RequiredReplicas: reqRepl}, nil
 	},
 }
+
+func queueBasedReplicas(queueLen float64, messagesPerWorker float64, offset int) int {
+	return int(math.Ceil(queueLen/messagesPerWorker)) + offset
+}